@@ -0,0 +1,74 @@
+package astro
+
+import (
+	"math"
+	"time"
+)
+
+// Mean irradiance at the top of the atmosphere in W/m^2
+const SolarConstant = 1361.0
+
+// Below this elevation the direct radiation is treated as diffuse, the projection
+// of the direct radiation onto a tilted surface is not stable that close to the horizon
+const minDirectElevation = 2.0
+
+// ExtraterrestrialIrradiance returns the irradiance at the top of the atmosphere on a surface
+// normal to the sun, corrected for the distance between earth and sun
+func ExtraterrestrialIrradiance(t time.Time) float64 {
+	dayOfYear := float64(t.UTC().YearDay())
+	return SolarConstant * (1 + 0.033*math.Cos(2*math.Pi*dayOfYear/365))
+}
+
+// SplitGlobalIrradiance splits the global horizontal irradiance into its direct and diffuse
+// horizontal parts using the Erbs correlation. elevation is the solar elevation in degrees.
+func SplitGlobalIrradiance(global float64, t time.Time, elevation float64) (float64, float64) {
+	if global <= 0 {
+		return 0, 0
+	}
+
+	if elevation < minDirectElevation {
+		return 0, global
+	}
+
+	clearness := global / (ExtraterrestrialIrradiance(t) * math.Sin(elevation*degToRad))
+	clearness = math.Min(clearness, 1)
+
+	var diffuseFraction float64
+	switch {
+	case clearness <= 0.22:
+		diffuseFraction = 1 - 0.09*clearness
+	case clearness <= 0.8:
+		diffuseFraction = 0.9511 - 0.1604*clearness + 4.388*math.Pow(clearness, 2) - 16.638*math.Pow(clearness, 3) + 12.336*math.Pow(clearness, 4)
+	default:
+		diffuseFraction = 0.165
+	}
+
+	diffuse := global * diffuseFraction
+	return global - diffuse, diffuse
+}
+
+// TiltedIrradiance returns the global irradiance on a tilted surface using the isotropic sky model.
+// direct and diffuse are the horizontal irradiances, elevation and azimuth the position of the sun,
+// tilt the angle of the surface to the horizontal and surfaceAzimuth its orientation clockwise from
+// north (180 = south), all in degrees. albedo is the reflectivity of the ground.
+func TiltedIrradiance(direct, diffuse, elevation, azimuth, tilt, surfaceAzimuth, albedo float64) float64 {
+	direct = math.Max(direct, 0)
+	diffuse = math.Max(diffuse, 0)
+	global := direct + diffuse
+
+	zenith := (90 - elevation) * degToRad
+	beta := tilt * degToRad
+
+	beam := 0.0
+	if elevation >= minDirectElevation {
+		cosIncidence := math.Cos(zenith)*math.Cos(beta) + math.Sin(zenith)*math.Sin(beta)*math.Cos((azimuth-surfaceAzimuth)*degToRad)
+		beam = direct * math.Max(cosIncidence, 0) / math.Cos(zenith)
+	} else {
+		diffuse += direct
+	}
+
+	sky := diffuse * (1 + math.Cos(beta)) / 2
+	ground := global * albedo * (1 - math.Cos(beta)) / 2
+
+	return beam + sky + ground
+}
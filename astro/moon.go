@@ -0,0 +1,45 @@
+package astro
+
+import (
+	"math"
+	"time"
+)
+
+// Mean distance between earth and sun in km
+const sunDistance = 149598000.0
+
+// moonCoordinates returns the right ascension, declination and the distance in km of the moon
+func moonCoordinates(d float64) (float64, float64, float64) {
+	meanLongitude := (218.316 + 13.176396*d) * degToRad
+	meanAnomaly := (134.963 + 13.064993*d) * degToRad
+	meanDistance := (93.272 + 13.229350*d) * degToRad
+
+	longitude := meanLongitude + 6.289*degToRad*math.Sin(meanAnomaly)
+	latitude := 5.128 * degToRad * math.Sin(meanDistance)
+	distance := 385001 - 20905*math.Cos(meanAnomaly)
+
+	return rightAscension(longitude, latitude), declination(longitude, latitude), distance
+}
+
+// MoonPhase returns the phase of the moon (0 new moon, 0.25 first quarter, 0.5 full moon,
+// 0.75 last quarter) and the illuminated fraction of its disk at the given time
+func MoonPhase(t time.Time) (float64, float64) {
+	d := daysSinceJ2000(t)
+
+	sunRa, sunDec := sunCoordinates(d)
+	moonRa, moonDec, moonDistance := moonCoordinates(d)
+
+	elongation := math.Acos(math.Sin(sunDec)*math.Sin(moonDec) + math.Cos(sunDec)*math.Cos(moonDec)*math.Cos(sunRa-moonRa))
+	inclination := math.Atan2(sunDistance*math.Sin(elongation), moonDistance-sunDistance*math.Cos(elongation))
+	angle := math.Atan2(math.Cos(sunDec)*math.Sin(sunRa-moonRa), math.Sin(sunDec)*math.Cos(moonDec)-math.Cos(sunDec)*math.Sin(moonDec)*math.Cos(sunRa-moonRa))
+
+	sign := 1.0
+	if angle < 0 {
+		sign = -1
+	}
+
+	phase := 0.5 + 0.5*inclination*sign/math.Pi
+	illumination := (1 + math.Cos(inclination)) / 2
+
+	return phase, illumination
+}
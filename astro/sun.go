@@ -0,0 +1,164 @@
+package astro
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	degToRad = math.Pi / 180
+	radToDeg = 180 / math.Pi
+
+	// Elevation of the upper limb of the sun at sunrise and sunset, including refraction
+	SunriseElevation = -0.833
+	// Elevation of the center of the sun at the begin and end of civil and nautical twilight
+	CivilTwilightElevation    = -6.0
+	NauticalTwilightElevation = -12.0
+
+	// Obliquity of the ecliptic
+	obliquity = 23.4397 * degToRad
+	// Correction of the transit for the mean solar time
+	transitOffset = 0.0009
+)
+
+// daysSinceJ2000 returns the days since 2000-01-01 12:00 UTC
+func daysSinceJ2000(t time.Time) float64 {
+	return float64(t.UTC().UnixMilli())/86400000.0 - 10957.5
+}
+
+func fromDaysSinceJ2000(d float64) time.Time {
+	return time.UnixMilli(int64(math.Round((d + 10957.5) * 86400000.0)))
+}
+
+func rightAscension(eclipticLongitude, eclipticLatitude float64) float64 {
+	return math.Atan2(math.Sin(eclipticLongitude)*math.Cos(obliquity)-math.Tan(eclipticLatitude)*math.Sin(obliquity), math.Cos(eclipticLongitude))
+}
+
+func declination(eclipticLongitude, eclipticLatitude float64) float64 {
+	return math.Asin(math.Sin(eclipticLatitude)*math.Cos(obliquity) + math.Cos(eclipticLatitude)*math.Sin(obliquity)*math.Sin(eclipticLongitude))
+}
+
+func solarMeanAnomaly(d float64) float64 {
+	return (357.5291 + 0.98560028*d) * degToRad
+}
+
+func eclipticLongitude(meanAnomaly float64) float64 {
+	center := (1.9148*math.Sin(meanAnomaly) + 0.02*math.Sin(2*meanAnomaly) + 0.0003*math.Sin(3*meanAnomaly)) * degToRad
+	perihelion := 102.9372 * degToRad
+
+	return meanAnomaly + center + perihelion + math.Pi
+}
+
+// sunCoordinates returns the right ascension and declination of the sun in radians
+func sunCoordinates(d float64) (float64, float64) {
+	l := eclipticLongitude(solarMeanAnomaly(d))
+	return rightAscension(l, 0), declination(l, 0)
+}
+
+// siderealTime returns the local sidereal time in radians
+func siderealTime(d, longitude float64) float64 {
+	return (280.16+360.9856235*d)*degToRad + longitude*degToRad
+}
+
+// horizontalPosition returns the elevation and the azimuth (clockwise from north) in degrees
+// of an object with the given equatorial coordinates
+func horizontalPosition(d, latitude, longitude, ra, dec float64) (float64, float64) {
+	h := siderealTime(d, longitude) - ra
+	phi := latitude * degToRad
+
+	elevation := math.Asin(math.Sin(phi)*math.Sin(dec) + math.Cos(phi)*math.Cos(dec)*math.Cos(h))
+	azimuth := math.Atan2(math.Sin(h), math.Cos(h)*math.Sin(phi)-math.Tan(dec)*math.Cos(phi)) + math.Pi
+
+	return elevation * radToDeg, math.Mod(azimuth*radToDeg, 360)
+}
+
+// SolarPosition returns the elevation of the center of the sun above the horizon and its azimuth,
+// clockwise from north, in degrees. Atmospheric refraction is not included.
+func SolarPosition(t time.Time, latitude, longitude float64) (float64, float64) {
+	d := daysSinceJ2000(t)
+	ra, dec := sunCoordinates(d)
+
+	return horizontalPosition(d, latitude, longitude, ra, dec)
+}
+
+// SolarElevation returns the elevation of the center of the sun above the horizon in degrees
+func SolarElevation(t time.Time, latitude, longitude float64) float64 {
+	elevation, _ := SolarPosition(t, latitude, longitude)
+	return elevation
+}
+
+// IsDay reports whether the sun is above the horizon at the given time and position
+func IsDay(t time.Time, latitude, longitude float64) bool {
+	return SolarElevation(t, latitude, longitude) > SunriseElevation
+}
+
+// SunEvents holds the times at which the sun crosses an elevation on one day.
+// Rise and Set are zero if the sun stays above (AlwaysAbove) or below the elevation the whole day.
+type SunEvents struct {
+	Rise        time.Time
+	Set         time.Time
+	AlwaysAbove bool
+}
+
+// Duration returns the time the sun spends above the elevation
+func (e SunEvents) Duration() time.Duration {
+	if e.Rise.IsZero() || e.Set.IsZero() {
+		if e.AlwaysAbove {
+			return 24 * time.Hour
+		}
+		return 0
+	}
+
+	return e.Set.Sub(e.Rise)
+}
+
+// solarTransit returns the days since J2000 of the solar noon closest to d, the number of the
+// solar cycle and the mean anomaly and ecliptic longitude of the sun at that time
+func solarTransit(d, longitude float64) (float64, float64, float64, float64) {
+	lw := -longitude * degToRad
+
+	cycle := math.Round(d - transitOffset - lw/(2*math.Pi))
+	approxTransit := transitOffset + lw/(2*math.Pi) + cycle
+
+	meanAnomaly := solarMeanAnomaly(approxTransit)
+	l := eclipticLongitude(meanAnomaly)
+
+	noon := approxTransit + 0.0053*math.Sin(meanAnomaly) - 0.0069*math.Sin(2*l)
+
+	return noon, cycle, meanAnomaly, l
+}
+
+// SolarNoon returns the time of the solar noon closest to t
+func SolarNoon(t time.Time, longitude float64) time.Time {
+	noon, _, _, _ := solarTransit(daysSinceJ2000(t), longitude)
+	return fromDaysSinceJ2000(noon)
+}
+
+// SunEventsAt returns the times at which the sun crosses the elevation (in degrees) around the
+// solar noon closest to t. Pass the local noon of a day to get the events of that day.
+func SunEventsAt(t time.Time, latitude, longitude, elevation float64) SunEvents {
+	lw := -longitude * degToRad
+	phi := latitude * degToRad
+
+	noon, cycle, meanAnomaly, l := solarTransit(daysSinceJ2000(t), longitude)
+	dec := declination(l, 0)
+
+	cosHourAngle := (math.Sin(elevation*degToRad) - math.Sin(phi)*math.Sin(dec)) / (math.Cos(phi) * math.Cos(dec))
+	if cosHourAngle < -1 {
+		return SunEvents{AlwaysAbove: true}
+	}
+	if cosHourAngle > 1 {
+		return SunEvents{}
+	}
+
+	hourAngle := math.Acos(cosHourAngle)
+
+	approxSet := transitOffset + (hourAngle+lw)/(2*math.Pi) + cycle
+	set := approxSet + 0.0053*math.Sin(meanAnomaly) - 0.0069*math.Sin(2*l)
+	rise := noon - (set - noon)
+
+	return SunEvents{
+		Rise: fromDaysSinceJ2000(rise),
+		Set:  fromDaysSinceJ2000(set),
+	}
+}
@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"errors"
+	"hstin/zephyr/config"
+	"sync"
+	"time"
+)
+
+var (
+	ErrMissingKey    = errors.New("missing API key")
+	ErrUnknownKey    = errors.New("invalid API key")
+	ErrRateLimited   = errors.New("rate limit exceeded")
+	ErrQuotaExceeded = errors.New("daily quota exceeded")
+)
+
+// Usage holds the counters of a key
+type Usage struct {
+	Name string `json:"name"`
+	// Accepted requests since the start of the server
+	Requests int64 `json:"requests"`
+	// Accepted requests of the current UTC day
+	RequestsToday int64 `json:"requests_today"`
+	// Requests rejected because of the rate limit or the quota
+	Rejected   int64 `json:"rejected"`
+	DailyQuota int64 `json:"daily_quota,omitempty"`
+	// Requests left today, only set if the key has a quota
+	RemainingToday int64 `json:"remaining_today,omitempty"`
+	// Time until the next request is allowed, only set if the request was rate limited
+	RetryAfter time.Duration `json:"-"`
+}
+
+type client struct {
+	lock sync.Mutex
+
+	name       string
+	bucket     *tokenBucket
+	dailyQuota int64
+
+	day           int64
+	requests      int64
+	requestsToday int64
+	rejected      int64
+}
+
+func (c *client) usage() Usage {
+	usage := Usage{
+		Name:          c.name,
+		Requests:      c.requests,
+		RequestsToday: c.requestsToday,
+		Rejected:      c.rejected,
+		DailyQuota:    c.dailyQuota,
+	}
+
+	if c.dailyQuota > 0 {
+		usage.RemainingToday = max(0, c.dailyQuota-c.requestsToday)
+	}
+
+	return usage
+}
+
+// Store checks API keys and keeps their rate limits, quotas and usage counters in memory
+type Store struct {
+	clients map[string]*client
+}
+
+// NewStore creates a store with the keys of the configuration and its keys file.
+// Keys without limits use the defaults of the configuration.
+func NewStore(cfg config.AuthConfig) (*Store, error) {
+	keys := cfg.Keys
+
+	if cfg.KeysFile != "" {
+		fileKeys, err := config.LoadKeysFile(cfg.KeysFile)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, fileKeys...)
+	}
+
+	store := &Store{clients: make(map[string]*client, len(keys))}
+
+	for _, key := range keys {
+		rateLimit, burst, dailyQuota := key.RateLimit, key.Burst, key.DailyQuota
+		if rateLimit == 0 {
+			rateLimit, burst = cfg.RateLimit, cfg.Burst
+		}
+		if dailyQuota == 0 {
+			dailyQuota = cfg.DailyQuota
+		}
+
+		c := &client{
+			name:       key.Name,
+			dailyQuota: dailyQuota,
+		}
+
+		if rateLimit > 0 {
+			c.bucket = newTokenBucket(rateLimit, burst)
+		}
+
+		store.clients[key.Key] = c
+	}
+
+	return store, nil
+}
+
+// Authorize counts a request of the key. It fails if the key is unknown, the rate limit is
+// exceeded or the daily quota is used up. The returned usage includes the request.
+func (s *Store) Authorize(key string) (Usage, error) {
+	if key == "" {
+		return Usage{}, ErrMissingKey
+	}
+
+	c, ok := s.clients[key]
+	if !ok {
+		return Usage{}, ErrUnknownKey
+	}
+
+	now := time.Now()
+	day := now.UTC().Unix() / 86400
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if day != c.day {
+		c.day = day
+		c.requestsToday = 0
+	}
+
+	if c.dailyQuota > 0 && c.requestsToday >= c.dailyQuota {
+		c.rejected++
+		return c.usage(), ErrQuotaExceeded
+	}
+
+	if c.bucket != nil {
+		if ok, retryAfter := c.bucket.take(now); !ok {
+			c.rejected++
+			usage := c.usage()
+			usage.RetryAfter = retryAfter
+			return usage, ErrRateLimited
+		}
+	}
+
+	c.requests++
+	c.requestsToday++
+
+	return c.usage(), nil
+}
+
+// Usage returns the counters of a key
+func (s *Store) Usage(key string) (Usage, bool) {
+	c, ok := s.clients[key]
+	if !ok {
+		return Usage{}, false
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if day := time.Now().UTC().Unix() / 86400; day != c.day {
+		c.day = day
+		c.requestsToday = 0
+	}
+
+	return c.usage(), true
+}
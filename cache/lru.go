@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// LRU keeps the most recently used entries in memory, entries expire after the TTL
+type LRU[V any] struct {
+	maxEntries int
+	ttl        time.Duration
+
+	lock    sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+type entry[V any] struct {
+	key     string
+	value   V
+	created time.Time
+}
+
+func NewLRU[V any](maxEntries int, ttl time.Duration) *LRU[V] {
+	return &LRU[V]{
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		entries:    make(map[string]*list.Element, maxEntries),
+		order:      list.New(),
+	}
+}
+
+func (c *LRU[V]) Get(key string) (V, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var zero V
+
+	element, ok := c.entries[key]
+	if !ok {
+		return zero, false
+	}
+
+	e := element.Value.(*entry[V])
+	if time.Since(e.created) > c.ttl {
+		c.order.Remove(element)
+		delete(c.entries, key)
+		return zero, false
+	}
+
+	c.order.MoveToFront(element)
+	return e.value, true
+}
+
+func (c *LRU[V]) Add(key string, value V) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		element.Value = &entry[V]{key: key, value: value, created: time.Now()}
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&entry[V]{key: key, value: value, created: time.Now()})
+
+	for c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*entry[V]).key)
+	}
+}
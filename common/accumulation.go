@@ -0,0 +1,140 @@
+package common
+
+import "math"
+
+// Deaccumulator converts fields accumulated since the start of the forecast (e.g. TOT_PREC)
+// into amounts per hour.
+//
+// The amounts are calculated in multiples of the storage scale of the parameter, so the stored
+// hourly values add up exactly to the accumulated total of the model. Decreasing totals, which
+// can occur due to the packing of the GRIB values, never result in negative amounts, the
+// difference is subtracted from the following intervals instead.
+type Deaccumulator struct {
+	scale float64
+	// Accumulated total of the first field in units of scale, the amounts are relative to it
+	baseUnits []int64
+	// Sum of all amounts returned so far in units of scale
+	emittedUnits []int64
+}
+
+func NewDeaccumulator(scale float64) *Deaccumulator {
+	if scale <= 0 {
+		scale = DefaultScale
+	}
+
+	return &Deaccumulator{scale: scale}
+}
+
+// Next takes the accumulated total at the end of an interval of the given number of hours and
+// returns the amount of every hour of that interval. The first call only sets the start of the
+// accumulation and returns nil. Cells with a NaN total return NaN amounts.
+func (d *Deaccumulator) Next(total []float64, hours int) [][]float64 {
+	if d.baseUnits == nil {
+		d.baseUnits = make([]int64, len(total))
+		d.emittedUnits = make([]int64, len(total))
+
+		for i, value := range total {
+			if !math.IsNaN(value) {
+				d.baseUnits[i] = int64(math.Round(value / d.scale))
+			}
+		}
+
+		return nil
+	}
+
+	if hours < 1 {
+		hours = 1
+	}
+
+	amounts := make([][]float64, hours)
+	for h := range amounts {
+		amounts[h] = make([]float64, len(total))
+	}
+
+	for i, value := range total {
+		if i >= len(d.baseUnits) {
+			break
+		}
+
+		if math.IsNaN(value) {
+			for h := range amounts {
+				amounts[h][i] = math.NaN()
+			}
+			continue
+		}
+
+		targetUnits := int64(math.Round(value/d.scale)) - d.baseUnits[i]
+
+		units := targetUnits - d.emittedUnits[i]
+		if units <= 0 {
+			continue
+		}
+
+		d.emittedUnits[i] = targetUnits
+
+		// Distribute the units evenly, the sum of all hours equals units
+		for h := int64(0); h < int64(hours); h++ {
+			hourUnits := units*(h+1)/int64(hours) - units*h/int64(hours)
+			amounts[h][i] = float64(hourUnits) * d.scale
+		}
+	}
+
+	return amounts
+}
+
+// Accumulator converts accumulated or averaged values, which may restart every few hours,
+// into totals accumulated since the start of the forecast that can be passed to a Deaccumulator
+type Accumulator struct {
+	stepType   StepType
+	resetHours int
+
+	windowStart int
+	// Total at the start of the current window
+	windowBase []float64
+	// Total of the last step
+	lastTotal []float64
+}
+
+func NewAccumulator(stepType StepType, resetHours int) *Accumulator {
+	return &Accumulator{stepType: stepType, resetHours: resetHours}
+}
+
+// windowStartOf returns the step at which the accumulation or averaging of the step started
+func (a *Accumulator) windowStartOf(step int) int {
+	if a.resetHours <= 0 || step <= 0 {
+		return 0
+	}
+	return ((step - 1) / a.resetHours) * a.resetHours
+}
+
+// Total returns the total since the start of the forecast for the values of the step,
+// steps have to be passed in ascending order
+func (a *Accumulator) Total(step int, values []float64) []float64 {
+	windowStart := a.windowStartOf(step)
+
+	if windowStart != a.windowStart {
+		// The last total is the start of the new window, if the step at the reset is missing
+		// the amount between the last step and the reset is lost
+		a.windowStart = windowStart
+		a.windowBase = a.lastTotal
+	}
+
+	hours := float64(step - windowStart)
+
+	total := make([]float64, len(values))
+	for i, value := range values {
+		if a.stepType == AVERAGED {
+			value *= hours
+		}
+
+		if i < len(a.windowBase) {
+			value += a.windowBase[i]
+		}
+
+		total[i] = value
+	}
+
+	a.lastTotal = total
+
+	return total
+}
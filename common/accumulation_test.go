@@ -0,0 +1,170 @@
+package common
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDeaccumulator(t *testing.T) {
+	tests := []struct {
+		name string
+		// Accumulated totals of a single cell, the first one starts the accumulation
+		totals []float64
+		// Hours of the interval ending at each total after the first
+		hours []int
+		want  [][]float64
+	}{
+		{
+			name:   "hourly steps",
+			totals: []float64{0, 0.5, 1.2, 1.2},
+			hours:  []int{1, 1, 1},
+			want:   [][]float64{{0.5}, {0.7}, {0}},
+		},
+		{
+			name:   "3 hour bucket",
+			totals: []float64{0, 1},
+			hours:  []int{3},
+			want:   [][]float64{{0.33, 0.33, 0.34}},
+		},
+		{
+			name:   "6 hour bucket",
+			totals: []float64{0.5, 0.57},
+			hours:  []int{6},
+			want:   [][]float64{{0.01, 0.01, 0.01, 0.01, 0.01, 0.02}},
+		},
+		{
+			name:   "hourly then 3 hour steps",
+			totals: []float64{0, 0.1, 0.3, 0.9},
+			hours:  []int{1, 1, 3},
+			want:   [][]float64{{0.1}, {0.2}, {0.2, 0.2, 0.2}},
+		},
+		{
+			name:   "decreasing total is clamped",
+			totals: []float64{0, 1, 0.9, 1.2},
+			hours:  []int{1, 1, 1},
+			want:   [][]float64{{1}, {0}, {0.2}},
+		},
+		{
+			name:   "total below the start is clamped",
+			totals: []float64{0.4, 0.2, 0.6},
+			hours:  []int{1, 2},
+			want:   [][]float64{{0}, {0.1, 0.1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDeaccumulator(DefaultScale)
+
+			if amounts := d.Next([]float64{tt.totals[0]}, 0); amounts != nil {
+				t.Fatalf("first call returned %v, want nil", amounts)
+			}
+
+			var sumUnits int64
+			for i, total := range tt.totals[1:] {
+				amounts := d.Next([]float64{total}, tt.hours[i])
+
+				if len(amounts) != len(tt.want[i]) {
+					t.Fatalf("interval %d: got %d hours, want %d", i, len(amounts), len(tt.want[i]))
+				}
+
+				for h, amount := range amounts {
+					if amount[0] < 0 {
+						t.Errorf("interval %d hour %d: negative amount %v", i, h, amount[0])
+					}
+					if math.Abs(amount[0]-tt.want[i][h]) > 1e-9 {
+						t.Errorf("interval %d hour %d: got %v, want %v", i, h, amount[0], tt.want[i][h])
+					}
+					sumUnits += int64(math.Round(amount[0] / DefaultScale))
+				}
+			}
+
+			// The stored amounts add up exactly to the total of the model
+			first := int64(math.Round(tt.totals[0] / DefaultScale))
+			last := int64(math.Round(tt.totals[len(tt.totals)-1] / DefaultScale))
+			if want := max(last-first, 0); sumUnits != want {
+				t.Errorf("amounts add up to %d units, want %d", sumUnits, want)
+			}
+		})
+	}
+}
+
+func TestDeaccumulatorNaN(t *testing.T) {
+	d := NewDeaccumulator(DefaultScale)
+	d.Next([]float64{0, 0}, 0)
+
+	amounts := d.Next([]float64{math.NaN(), 0.3}, 3)
+
+	for h := range amounts {
+		if !math.IsNaN(amounts[h][0]) {
+			t.Errorf("hour %d: got %v for a NaN total, want NaN", h, amounts[h][0])
+		}
+		if math.Abs(amounts[h][1]-0.1) > 1e-9 {
+			t.Errorf("hour %d: got %v, want 0.1", h, amounts[h][1])
+		}
+	}
+}
+
+func TestAccumulator(t *testing.T) {
+	tests := []struct {
+		name       string
+		stepType   StepType
+		resetHours int
+		steps      []int
+		values     []float64
+		want       []float64
+	}{
+		{
+			name:     "accumulated since the start",
+			stepType: ACCUMULATED,
+			steps:    []int{1, 2, 3, 6},
+			values:   []float64{0.1, 0.3, 0.3, 0.9},
+			want:     []float64{0.1, 0.3, 0.3, 0.9},
+		},
+		{
+			name:       "accumulated with 6 hour reset",
+			stepType:   ACCUMULATED,
+			resetHours: 6,
+			steps:      []int{3, 6, 9, 12, 15},
+			values:     []float64{1, 2, 0.5, 1.5, 0.25},
+			want:       []float64{1, 2, 2.5, 3.5, 3.75},
+		},
+		{
+			name:       "averaged with 6 hour reset",
+			stepType:   AVERAGED,
+			resetHours: 6,
+			steps:      []int{3, 6, 9, 12},
+			values:     []float64{2, 1, 4, 3},
+			want:       []float64{6, 6, 18, 24},
+		},
+		{
+			name:     "averaged since the start",
+			stepType: AVERAGED,
+			steps:    []int{1, 3, 6},
+			values:   []float64{10, 20, 15},
+			want:     []float64{10, 60, 90},
+		},
+		{
+			name:       "missing step at the reset",
+			stepType:   ACCUMULATED,
+			resetHours: 6,
+			steps:      []int{3, 9},
+			values:     []float64{1, 0.5},
+			want:       []float64{1, 1.5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewAccumulator(tt.stepType, tt.resetHours)
+
+			for i, step := range tt.steps {
+				total := a.Total(step, []float64{tt.values[i]})
+
+				if math.Abs(total[0]-tt.want[i]) > 1e-9 {
+					t.Errorf("step %d: got total %v, want %v", step, total[0], tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package common
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Downloads are not started while the available memory of the system is below this value
+const minAvailableMemory = 256 << 20
+
+// Interval in which a waiting download checks the available memory again
+const budgetPollInterval = time.Second
+
+// MemoryBudget limits the memory held by downloaded steps that are not written to the ND files yet.
+// New downloads wait while the held steps exceed the limit or the system runs low on available
+// memory, the page cache counts as available.
+// At least one download is always allowed, so the ingestion can not stall. A nil MemoryBudget
+// does not limit anything.
+type MemoryBudget struct {
+	limit   uint64
+	mu      sync.Mutex
+	used    uint64
+	changed chan struct{}
+}
+
+// NewMemoryBudget returns a budget of limit bytes, a limit of 0 uses half of the available memory
+func NewMemoryBudget(limit uint64) *MemoryBudget {
+	if limit == 0 {
+		limit = GetAvailableMemory() / 2
+	}
+
+	return &MemoryBudget{limit: limit, changed: make(chan struct{})}
+}
+
+// Wait blocks until a new download fits into the budget or ctx is done
+func (b *MemoryBudget) Wait(ctx context.Context) error {
+	if b == nil {
+		return nil
+	}
+
+	for {
+		b.mu.Lock()
+		used, changed := b.used, b.changed
+		b.mu.Unlock()
+
+		if used == 0 || (used < b.limit && !lowMemory()) {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		case <-time.After(budgetPollInterval):
+		}
+	}
+}
+
+// Hold adds size bytes to the used memory
+func (b *MemoryBudget) Hold(size int) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	b.used += uint64(size)
+	b.mu.Unlock()
+}
+
+// Release returns size bytes to the budget and wakes up waiting downloads
+func (b *MemoryBudget) Release(size int) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	b.used -= min(b.used, uint64(size))
+	close(b.changed)
+	b.changed = make(chan struct{})
+	b.mu.Unlock()
+}
+
+func lowMemory() bool {
+	available := GetAvailableMemory()
+	return available != 0 && available < minAvailableMemory
+}
@@ -0,0 +1,86 @@
+package common
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMemoryBudgetWait(t *testing.T) {
+	budget := NewMemoryBudget(10)
+
+	// An empty budget never blocks, even if the system is low on memory
+	if err := budget.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	budget.Hold(10)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- budget.Wait(context.Background())
+	}()
+
+	select {
+	case err := <-done:
+		t.Fatalf("Wait returned %v while the budget is used up", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	budget.Release(10)
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Wait did not return after Release")
+	}
+}
+
+func TestMemoryBudgetWaitCanceled(t *testing.T) {
+	budget := NewMemoryBudget(10)
+	budget.Hold(10)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := budget.Wait(ctx); err != context.DeadlineExceeded {
+		t.Errorf("got %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestParseMemAvailable(t *testing.T) {
+	tests := []struct {
+		name    string
+		meminfo string
+		want    uint64
+		wantOK  bool
+	}{
+		{
+			name:    "page cache counts as available",
+			meminfo: "MemTotal:        6158152 kB\nMemFree:          210736 kB\nMemAvailable:    5468892 kB\nCached:          4721732 kB\n",
+			want:    5468892 << 10,
+			wantOK:  true,
+		},
+		{
+			name:    "old kernel without MemAvailable",
+			meminfo: "MemTotal:        6158152 kB\nMemFree:          210736 kB\n",
+		},
+		{
+			name:    "invalid value",
+			meminfo: "MemAvailable:    unknown kB\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseMemAvailable(strings.NewReader(tt.meminfo))
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("got %d, %t, want %d, %t", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package common
+
+import "fmt"
+
+// Condition is the normalized description of a WMO weather code (code table 4677, ww)
+type Condition struct {
+	Code int    `json:"code"`
+	Text string `json:"text"`
+	Icon string `json:"icon"`
+}
+
+type conditionDescription struct {
+	text map[string]string
+	// Icon key, day and night variants get a "-day" or "-night" suffix
+	icon     string
+	dayNight bool
+}
+
+// ConditionLanguages lists the languages of the condition texts, the first entry is the default
+var ConditionLanguages = []string{"en", "de"}
+
+// Codes reported by ICON in the WW parameter
+var conditionDescriptions = map[int]conditionDescription{
+	0:  {text: map[string]string{"en": "Clear sky", "de": "Wolkenlos"}, icon: "clear", dayNight: true},
+	1:  {text: map[string]string{"en": "Mainly clear", "de": "Heiter"}, icon: "mostly-clear", dayNight: true},
+	2:  {text: map[string]string{"en": "Partly cloudy", "de": "Bewölkt"}, icon: "partly-cloudy", dayNight: true},
+	3:  {text: map[string]string{"en": "Overcast", "de": "Bedeckt"}, icon: "overcast"},
+	45: {text: map[string]string{"en": "Fog", "de": "Nebel"}, icon: "fog"},
+	48: {text: map[string]string{"en": "Depositing rime fog", "de": "Nebel mit Reifbildung"}, icon: "fog"},
+	51: {text: map[string]string{"en": "Light drizzle", "de": "Leichter Sprühregen"}, icon: "drizzle"},
+	53: {text: map[string]string{"en": "Moderate drizzle", "de": "Mäßiger Sprühregen"}, icon: "drizzle"},
+	55: {text: map[string]string{"en": "Dense drizzle", "de": "Starker Sprühregen"}, icon: "drizzle"},
+	56: {text: map[string]string{"en": "Light freezing drizzle", "de": "Leichter gefrierender Sprühregen"}, icon: "freezing-drizzle"},
+	57: {text: map[string]string{"en": "Dense freezing drizzle", "de": "Starker gefrierender Sprühregen"}, icon: "freezing-drizzle"},
+	61: {text: map[string]string{"en": "Slight rain", "de": "Leichter Regen"}, icon: "rain"},
+	63: {text: map[string]string{"en": "Moderate rain", "de": "Mäßiger Regen"}, icon: "rain"},
+	65: {text: map[string]string{"en": "Heavy rain", "de": "Starker Regen"}, icon: "heavy-rain"},
+	66: {text: map[string]string{"en": "Light freezing rain", "de": "Leichter gefrierender Regen"}, icon: "freezing-rain"},
+	67: {text: map[string]string{"en": "Heavy freezing rain", "de": "Starker gefrierender Regen"}, icon: "freezing-rain"},
+	71: {text: map[string]string{"en": "Slight snow fall", "de": "Leichter Schneefall"}, icon: "snow"},
+	73: {text: map[string]string{"en": "Moderate snow fall", "de": "Mäßiger Schneefall"}, icon: "snow"},
+	75: {text: map[string]string{"en": "Heavy snow fall", "de": "Starker Schneefall"}, icon: "heavy-snow"},
+	77: {text: map[string]string{"en": "Snow grains", "de": "Schneegriesel"}, icon: "snow"},
+	80: {text: map[string]string{"en": "Slight rain showers", "de": "Leichte Regenschauer"}, icon: "showers", dayNight: true},
+	81: {text: map[string]string{"en": "Moderate rain showers", "de": "Mäßige Regenschauer"}, icon: "showers", dayNight: true},
+	82: {text: map[string]string{"en": "Violent rain showers", "de": "Heftige Regenschauer"}, icon: "heavy-rain"},
+	85: {text: map[string]string{"en": "Slight snow showers", "de": "Leichte Schneeschauer"}, icon: "snow-showers", dayNight: true},
+	86: {text: map[string]string{"en": "Heavy snow showers", "de": "Starke Schneeschauer"}, icon: "heavy-snow"},
+	95: {text: map[string]string{"en": "Thunderstorm", "de": "Gewitter"}, icon: "thunderstorm"},
+	96: {text: map[string]string{"en": "Thunderstorm with slight hail", "de": "Gewitter mit leichtem Hagel"}, icon: "thunderstorm-hail"},
+	99: {text: map[string]string{"en": "Thunderstorm with heavy hail", "de": "Gewitter mit starkem Hagel"}, icon: "thunderstorm-hail"},
+}
+
+// Codes from this value on always dominate a day, even if they occur only once
+const severeConditionCode = 95
+
+// Minimum number of steps a code has to occur on a day to be the dominant condition
+const dominantConditionSteps = 3
+
+func IsConditionLanguage(language string) bool {
+	for _, l := range ConditionLanguages {
+		if l == language {
+			return true
+		}
+	}
+	return false
+}
+
+// DecodeCondition returns the description of a WMO weather code in the given language.
+// isDay selects the day or night variant of the icon. Unknown codes return the icon "unknown".
+func DecodeCondition(code int, isDay bool, language string) Condition {
+	description, ok := conditionDescriptions[code]
+	if !ok {
+		return Condition{
+			Code: code,
+			Text: fmt.Sprintf("WMO %d", code),
+			Icon: "unknown",
+		}
+	}
+
+	text, ok := description.text[language]
+	if !ok {
+		text = description.text[ConditionLanguages[0]]
+	}
+
+	icon := description.icon
+	if description.dayNight {
+		if isDay {
+			icon += "-day"
+		} else {
+			icon += "-night"
+		}
+	}
+
+	return Condition{
+		Code: code,
+		Text: text,
+		Icon: icon,
+	}
+}
+
+// DominantCondition returns the most significant code of a day. The higher a WMO code, the more
+// significant the weather, but a code has to occur in at least a few steps to dominate the day,
+// except for thunderstorms. If no code occurs often enough, the most frequent code is returned.
+// Returns -1 if codes is empty.
+func DominantCondition(codes []int) int {
+	if len(codes) == 0 {
+		return -1
+	}
+
+	counts := make(map[int]int, len(codes))
+	for _, code := range codes {
+		counts[code]++
+	}
+
+	minSteps := dominantConditionSteps
+	if len(codes) < minSteps {
+		minSteps = 1
+	}
+
+	dominant := -1
+	mostFrequent := -1
+
+	for code, count := range counts {
+		if (count >= minSteps || code >= severeConditionCode) && code > dominant {
+			dominant = code
+		}
+
+		if mostFrequent == -1 || count > counts[mostFrequent] || (count == counts[mostFrequent] && code > mostFrequent) {
+			mostFrequent = code
+		}
+	}
+
+	if dominant == -1 {
+		return mostFrequent
+	}
+
+	return dominant
+}
@@ -0,0 +1,76 @@
+package common
+
+import (
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/hstin-de/ndfile"
+)
+
+type LevelType int
+
+const (
+	SINGLE_LEVEL LevelType = iota
+	PRESSURE_LEVEL
+	HEIGHT_LEVEL
+	MODEL_LEVEL
+)
+
+// Level describes the vertical level of a parameter. Value is given in hPa for
+// pressure levels, in meters above ground for height levels and as the level
+// number for model levels. Single-level parameters ignore the value.
+type Level struct {
+	Type  LevelType
+	Value int
+}
+
+// String returns the suffix used in parameter and file names, e.g. "850hPa", "80m" or "ml64".
+// Single-level parameters return an empty string.
+func (l Level) String() string {
+	switch l.Type {
+	case PRESSURE_LEVEL:
+		return fmt.Sprintf("%dhPa", l.Value)
+	case HEIGHT_LEVEL:
+		return fmt.Sprintf("%dm", l.Value)
+	case MODEL_LEVEL:
+		return fmt.Sprintf("ml%d", l.Value)
+	}
+	return ""
+}
+
+// NDFileName returns the path of the ND file of a parameter for the given day.
+// Single-level parameters are stored as "<id>_<day>.nd", all other levels as "<id>_<level>_<day>.nd".
+// Parameters with another scale or offset than the default add their encoding before the day,
+// e.g. "<id>_s0.002_<day>.nd", so files written with a different encoding are never misread.
+func NDFileName(rootPath string, parameter ParameterOptions, daysSinceEpoch int) string {
+	name := fmt.Sprintf("%d", parameter.ParameterID)
+
+	if parameter.Level.Type != SINGLE_LEVEL {
+		name += "_" + parameter.Level.String()
+	}
+
+	if encoding := parameter.encoding(); encoding != "" {
+		name += "_" + encoding
+	}
+
+	return path.Join(rootPath, fmt.Sprintf("%s_%d.nd", name, daysSinceEpoch))
+}
+
+// AddGrib stores the GRIB file in the ND file of the parameter, the file is created if it does not exist yet.
+// The values are encoded with the scale and offset of the parameter, the DataValues of grib are not modified.
+func AddGrib(NDFileManager *ndfile.NDFileManager, parameter ParameterOptions, grib ndfile.GRIBFile) {
+	fileName := NDFileName(NDFileManager.RootPath, parameter, CalculateDaysSinceEpoch(grib.ReferenceTime))
+
+	encodedValues := make([]float64, len(grib.DataValues))
+	for i, value := range grib.DataValues {
+		encodedValues[i] = parameter.Encode(value)
+	}
+	grib.DataValues = encodedValues
+
+	if _, err := os.Stat(fileName); os.IsNotExist(err) {
+		NDFileManager.CreateNDFile(fileName, grib)
+	} else {
+		NDFileManager.AddToNDFile(fileName, grib)
+	}
+}
@@ -0,0 +1,50 @@
+package common
+
+import "testing"
+
+func TestNDFileName(t *testing.T) {
+	tests := []struct {
+		name      string
+		parameter ParameterOptions
+		want      string
+	}{
+		{
+			name:      "default encoding",
+			parameter: ParameterOptions{ParameterID: 2},
+			want:      "data/2_19800.nd",
+		},
+		{
+			name:      "explicit default scale",
+			parameter: ParameterOptions{ParameterID: 2, Scale: DefaultScale},
+			want:      "data/2_19800.nd",
+		},
+		{
+			name:      "level",
+			parameter: ParameterOptions{ParameterID: 2, Level: Level{Type: PRESSURE_LEVEL, Value: 850}},
+			want:      "data/2_850hPa_19800.nd",
+		},
+		{
+			name:      "scale",
+			parameter: ParameterOptions{ParameterID: 2, Scale: 0.002},
+			want:      "data/2_s0.002_19800.nd",
+		},
+		{
+			name:      "scale and offset",
+			parameter: ParameterOptions{ParameterID: 2, Scale: 1, Offset: 100000},
+			want:      "data/2_s1o100000_19800.nd",
+		},
+		{
+			name:      "offset with default scale and level",
+			parameter: ParameterOptions{ParameterID: 2, Offset: -50, Level: Level{Type: HEIGHT_LEVEL, Value: 80}},
+			want:      "data/2_80m_s0.01o-50_19800.nd",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NDFileName("data", tt.parameter, 19800); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,19 +1,31 @@
 package common
 
 import (
-	"fmt"
-	"sync"
+	"bufio"
+	"io"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 
-	"github.com/hstin-de/ndfile"
 	"golang.org/x/sys/unix"
+	. "hstin/zephyr/helper"
 )
 
 type BaseModel interface {
 	GetModelName() string
 	GetRootPath() string
 	GetParentModel() BaseModel
-	DowloadParameter(parameter []string, fast bool) error
+	DowloadParameter(parameter []string, options DownloadOptions) error
+}
+
+type DownloadOptions struct {
+	// Download the parameters in parallel instead of one after another
+	Fast bool
+	// Limits the memory of downloaded steps that are not written yet, may be nil
+	Budget *MemoryBudget
+	// Limits the parallel downloads of all models, may be nil
+	Scheduler *Scheduler
 }
 
 func CalculateDaysSinceEpoch(t time.Time) int {
@@ -24,103 +36,62 @@ func GetFreeMemory() uint64 {
 	var info unix.Sysinfo_t
 	err := unix.Sysinfo(&info)
 	if err != nil {
-		fmt.Println("Error getting system info:", err)
+		Log.Error().Err(err).Msg("Error getting system info")
 		return 0
 	}
 	return uint64(info.Freeram) * uint64(info.Unit)
 }
 
-func ProcessParameter(param string, downloadedGribFiles map[string]map[int][]byte, breakPoint int, wg *sync.WaitGroup, NDFileManager *ndfile.NDFileManager) {
-	defer wg.Done()
-	var parsedParameter ParameterOptions
-	var ok bool
-
-	if parsedParameter, ok = Parameters[param]; !ok {
-		return
+// GetAvailableMemory returns the memory that can be used without swapping, including the page
+// cache the kernel can reclaim. It falls back to the free memory if /proc/meminfo has no
+// MemAvailable entry.
+func GetAvailableMemory() uint64 {
+	file, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return GetFreeMemory()
 	}
+	defer file.Close()
 
-	newLength := breakPoint + (len(downloadedGribFiles[param])-(breakPoint+1))*3
-
-	var loadedGribFiles map[int][]byte = make(map[int][]byte, newLength)
-
-	for idx, gribFile := range downloadedGribFiles[param] {
-		loadedGribFiles[idx] = gribFile
+	if available, ok := parseMemAvailable(file); ok {
+		return available
 	}
 
-	downloadedGribFiles[param] = nil
-
-	var previousData []float64
-
-	for step := 0; step <= newLength; step++ {
-
-		var currentGrib ndfile.GRIBFile
-
-		if _, ok := loadedGribFiles[step]; !ok {
-			// Hour not available, interpolate
-			prevIndex := (step / 3) * 3
-			nextIndex := prevIndex + 3
-
-			if nextIndex <= newLength {
-				prevFile := ndfile.ProcessGRIB(loadedGribFiles[prevIndex])
-				nextFile := ndfile.ProcessGRIB(loadedGribFiles[nextIndex])
-
-				prevTime := prevFile.ReferenceTime
-				nextTime := nextFile.ReferenceTime
-
-				// Calculate the interpolated time
-				interpolatedTime := prevTime.Add(time.Duration(step-prevIndex) * (nextTime.Sub(prevTime) / 3))
-
-				// Interpolate DataValues
-				interpolatedDataValues := make([]float64, len(prevFile.DataValues))
-
-				if parsedParameter.InterpolationMethod == COPY {
-					copy(interpolatedDataValues, prevFile.DataValues)
-				} else if parsedParameter.InterpolationMethod == LINEAR {
-
-					for j := range interpolatedDataValues {
-						prevValue := prevFile.DataValues[j]
-						nextValue := nextFile.DataValues[j]
-						interpolatedDataValues[j] = prevValue + float64(step-prevIndex)*(nextValue-prevValue)/3
-					}
-				}
-
-				currentGrib = prevFile
+	return GetFreeMemory()
+}
 
-				copy(currentGrib.DataValues, interpolatedDataValues)
-				currentGrib.ReferenceTime = interpolatedTime
-			}
-		} else {
-			// Hour available
-			currentGrib = ndfile.ProcessGRIB(loadedGribFiles[step])
+// parseMemAvailable reads the MemAvailable entry of /proc/meminfo in bytes
+func parseMemAvailable(r io.Reader) (uint64, bool) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] != "MemAvailable:" {
+			continue
 		}
 
-		if parsedParameter.StepType == ACCUMULATED {
-			// Accumulated data, subtract previous data
-
-			// setup previous data
-			if step == 0 {
-				previousData = currentGrib.DataValues
-			} else {
-
-				origData := currentGrib.DataValues
-
-				currentGrib.ReferenceTime = currentGrib.ReferenceTime.Add(time.Duration(step) * time.Hour)
+		kB, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return 0, false
+		}
 
-				for j := 0; j < len(currentGrib.DataValues); j++ {
-					currentGrib.DataValues[j] -= previousData[j]
-				}
+		return kB << 10, true
+	}
 
-				copy(previousData, origData)
+	return 0, false
+}
 
-				NDFileManager.AddGrib(currentGrib)
+// interpolateValues returns a new slice with the values hour hours after previous on the way to next
+func interpolateValues(previous, next []float64, hour, hours int, method InterpolationMethod) []float64 {
+	values := make([]float64, len(previous))
 
-				currentGrib.DataValues = nil
-				currentGrib = ndfile.GRIBFile{}
-			}
-		} else {
-			// Instantaneous data
-			NDFileManager.AddGrib(currentGrib)
-		}
+	if method == COPY || len(next) != len(previous) {
+		copy(values, previous)
+		return values
+	}
 
+	fraction := float64(hour) / float64(hours)
+	for j := range values {
+		values[j] = previous[j] + fraction*(next[j]-previous[j])
 	}
+
+	return values
 }
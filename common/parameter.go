@@ -1,6 +1,12 @@
 package common
 
-import "time"
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+	"time"
+)
 
 var epochTime = time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
 
@@ -15,7 +21,10 @@ type StepType int
 
 const (
 	INSTANT StepType = iota
+	// Accumulated since the start of the forecast or the last reset
 	ACCUMULATED
+	// Averaged since the start of the forecast or the last reset, e.g. radiation fluxes
+	AVERAGED
 )
 
 type ParameterOptions struct {
@@ -24,19 +33,141 @@ type ParameterOptions struct {
 	Unit                string
 	InterpolationMethod InterpolationMethod
 	StepType            StepType
+	// GRIB2 product definition of the parameter, used to derive the ParameterID
+	Discipline int
+	Category   int
+	Number     int
+	// Vertical level, parameters on other levels than single-level are stored in separate ND files
+	Level Level
+	// Values are stored as int16 in the ND files: value = stored * Scale + Offset.
+	// A Scale of 0 uses DefaultScale.
+	Scale  float64
+	Offset float64
+	// Accumulated and averaged parameters restart every ResetHours hours of the forecast (e.g. 6 for GFS),
+	// 0 if they run from the start of the forecast
+	ResetHours int
+	// Source variable names keyed by provider ("dwd", "noaa") or model name.
+	// An entry for a model takes precedence over the entry for its provider.
+	Sources map[string]string
+	// Colors of the map tiles sorted by value, empty if the parameter can not be rendered
+	ColorRamp []ColorStop
+}
+
+type ColorStop struct {
+	Value float64
+	Color color.NRGBA
+}
+
+// DefaultScale stores values with two decimal places
+const DefaultScale = 0.01
+
+// ndfile multiplies every value by this factor before converting it to int16
+const ndFileScaleFactor = 100.0
+
+// Parameters holds all registered parameters by name, see RegisterParameter
+var Parameters map[string]ParameterOptions = map[string]ParameterOptions{}
+
+// GRIBParameterID derives the ParameterID from the GRIB2 discipline, category and number.
+// It matches the type ndfile stores in the header and uses in the ND file names.
+func GRIBParameterID(discipline, category, number int) int {
+	return (discipline & 0xFF) | ((category & 0xFF) << 8) | ((number & 0xFF) << 16)
+}
+
+// RegisterParameter adds a parameter to the registry. The ParameterID is derived from
+// the GRIB2 codes of the parameter, so the ND file names stay the same across restarts.
+func RegisterParameter(name string, options ParameterOptions) error {
+	if name == "" {
+		return fmt.Errorf("parameter name must not be empty")
+	}
+
+	if _, exists := Parameters[name]; exists {
+		return fmt.Errorf("parameter '%s' is already registered", name)
+	}
+
+	for _, code := range []int{options.Discipline, options.Category, options.Number} {
+		if code < 0 || code > 255 {
+			return fmt.Errorf("parameter '%s': GRIB codes must be between 0 and 255", name)
+		}
+	}
+
+	if options.Scale < 0 {
+		return fmt.Errorf("parameter '%s': scale must not be negative", name)
+	}
+
+	if options.Scale == 0 {
+		options.Scale = DefaultScale
+	}
+
+	options.DisplayName = name
+	options.ParameterID = GRIBParameterID(options.Discipline, options.Category, options.Number)
+
+	for otherName, other := range Parameters {
+		if other.ParameterID == options.ParameterID && other.Level == options.Level {
+			return fmt.Errorf("parameter '%s' has the same GRIB codes and level as '%s'", name, otherName)
+		}
+	}
+
+	Parameters[name] = options
+
+	return nil
+}
+
+// ResetParameters removes all registered parameters
+func ResetParameters() {
+	Parameters = map[string]ParameterOptions{}
+}
+
+// Source returns the name of the source variable of the parameter for the given provider and model
+func (p ParameterOptions) Source(provider, model string) (string, bool) {
+	if source, ok := p.Sources[model]; ok {
+		return source, true
+	}
+
+	source, ok := p.Sources[provider]
+	return source, ok
+}
+
+// Decode converts a value read from an ND file
+func (p ParameterOptions) Decode(stored int16) float64 {
+	return float64(stored)*p.scale() + p.Offset
+}
+
+// Encode converts a value so that ndfile, which multiplies it by 100 and truncates it to int16,
+// stores round((value - Offset) / Scale). Values are clamped to the int16 range, NaN is stored as missing value.
+func (p ParameterOptions) Encode(value float64) float64 {
+	if math.IsNaN(value) {
+		return (32767 + 0.5) / ndFileScaleFactor
+	}
+
+	stored := math.Round((value - p.Offset) / p.scale())
+	stored = math.Max(-32767, math.Min(32766, stored))
+
+	// Move the value to the middle of the interval so the truncation always results in the rounded value
+	if stored < 0 {
+		return (stored - 0.5) / ndFileScaleFactor
+	}
+	return (stored + 0.5) / ndFileScaleFactor
+}
+
+// encoding returns the scale and offset as used in the ND file names, e.g. "s0.002" or "s1o100000".
+// It is empty for the default encoding, which is also the encoding of files written before the
+// scale and offset were configurable.
+func (p ParameterOptions) encoding() string {
+	if p.scale() == DefaultScale && p.Offset == 0 {
+		return ""
+	}
+
+	encoding := "s" + strconv.FormatFloat(p.scale(), 'g', -1, 64)
+	if p.Offset != 0 {
+		encoding += "o" + strconv.FormatFloat(p.Offset, 'g', -1, 64)
+	}
+
+	return encoding
 }
 
-var Parameters map[string]ParameterOptions = map[string]ParameterOptions{
-	"temperature":          {ParameterID: 0, DisplayName: "temperature", Unit: "°F", InterpolationMethod: LINEAR, StepType: INSTANT},
-	"clouds":               {ParameterID: 67072, DisplayName: "clouds", Unit: "%", InterpolationMethod: LINEAR, StepType: INSTANT},
-	"condition":            {ParameterID: 1643264, DisplayName: "condition", Unit: "", InterpolationMethod: COPY, StepType: INSTANT},
-	"cape":                 {ParameterID: 395008, DisplayName: "cape", Unit: "J/kg", InterpolationMethod: LINEAR, StepType: INSTANT},
-	"wind_u":               {ParameterID: 131584, DisplayName: "wind_u", Unit: "m/s", InterpolationMethod: LINEAR, StepType: INSTANT},
-	"wind_v":               {ParameterID: 197120, DisplayName: "wind_v", Unit: "m/s", InterpolationMethod: LINEAR, StepType: INSTANT},
-	"relative_humidity":    {ParameterID: 65792, DisplayName: "relative_humidity", Unit: "%", InterpolationMethod: LINEAR, StepType: INSTANT},
-	"surface_pressure":     {ParameterID: 768, DisplayName: "surface_pressure", Unit: "Pa", InterpolationMethod: LINEAR, StepType: INSTANT},
-	"dewpoint":             {ParameterID: 393216, DisplayName: "dewpoint", Unit: "°F", InterpolationMethod: LINEAR, StepType: INSTANT},
-	"snow_depth":           {ParameterID: 721152, DisplayName: "snow_depth", Unit: "m", InterpolationMethod: LINEAR, StepType: INSTANT},
-	"surface_pressure_msl": {ParameterID: 66304, DisplayName: "surface_pressure_msl", Unit: "Pa", InterpolationMethod: LINEAR, StepType: INSTANT},
-	"precipitation":        {ParameterID: 3408128, DisplayName: "precipitation", Unit: "kg m^-2", InterpolationMethod: LINEAR, StepType: ACCUMULATED},
+func (p ParameterOptions) scale() float64 {
+	if p.Scale == 0 {
+		return DefaultScale
+	}
+	return p.Scale
 }
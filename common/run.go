@@ -0,0 +1,53 @@
+package common
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"time"
+)
+
+// Name of the file in the root path of a model that describes the last ingested run
+const runInfoFileName = "run.json"
+
+// RunInfo describes the last model run that was written to the ND files of a model
+type RunInfo struct {
+	// Reference time of the run
+	Run time.Time `json:"run"`
+	// Time the ingestion of the run finished
+	Ingested time.Time `json:"ingested"`
+	// Time the next run is expected to be available for download
+	NextRun time.Time `json:"next_run"`
+}
+
+// WriteRunInfo replaces the run info of the model, the file is renamed into place so readers
+// never see a partially written file
+func WriteRunInfo(rootPath string, info RunInfo) error {
+	data, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+
+	fileName := path.Join(rootPath, runInfoFileName)
+
+	if err := os.WriteFile(fileName+".tmp", data, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(fileName+".tmp", fileName)
+}
+
+// ReadRunInfo returns the run info of the model, an error is returned if no run was ingested yet
+func ReadRunInfo(rootPath string) (RunInfo, error) {
+	data, err := os.ReadFile(path.Join(rootPath, runInfoFileName))
+	if err != nil {
+		return RunInfo{}, err
+	}
+
+	var info RunInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return RunInfo{}, err
+	}
+
+	return info, nil
+}
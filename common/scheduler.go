@@ -0,0 +1,140 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"hstin/zephyr/source"
+	"io"
+	"math/rand"
+	"sync"
+	"time"
+
+	. "hstin/zephyr/helper"
+)
+
+const (
+	// Number of attempts of a request before the download of the file fails
+	maxAttempts = 6
+	// Delay before the first retry, it doubles with every attempt up to maxBackoff
+	baseBackoff = time.Second
+	maxBackoff  = time.Minute
+)
+
+// Scheduler bounds the downloads of all models. A worker slot is held for every file that is
+// downloaded, a connection slot of the host for every request to a server. A nil Scheduler
+// does not limit anything but still retries failed requests.
+type Scheduler struct {
+	workers   chan struct{}
+	hostLimit int
+
+	mu    sync.Mutex
+	hosts map[string]chan struct{}
+}
+
+// NewScheduler returns a scheduler with concurrency workers and at most hostConcurrency
+// parallel requests per host
+func NewScheduler(concurrency, hostConcurrency int) *Scheduler {
+	return &Scheduler{
+		workers:   make(chan struct{}, max(concurrency, 1)),
+		hostLimit: max(hostConcurrency, 1),
+		hosts:     make(map[string]chan struct{}),
+	}
+}
+
+// Acquire blocks until a worker is free or ctx is done
+func (s *Scheduler) Acquire(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+
+	select {
+	case s.workers <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Release frees the worker taken by Acquire
+func (s *Scheduler) Release() {
+	if s == nil {
+		return
+	}
+
+	<-s.workers
+}
+
+func (s *Scheduler) host(host string) chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	connections, ok := s.hosts[host]
+	if !ok {
+		connections = make(chan struct{}, s.hostLimit)
+		s.hosts[host] = connections
+	}
+
+	return connections
+}
+
+// Fetch opens the file of the source and passes its content to handle. Network errors, including
+// errors reading the body in handle, and responses with status 429 or 5xx are retried with
+// exponential backoff and jitter, a Retry-After header of the server takes precedence. Missing
+// files and other errors of handle, e.g. a file that can not be decoded, are not retried.
+func (s *Scheduler) Fetch(ctx context.Context, src source.Source, path string, r *source.Range, handle func(io.Reader) error) error {
+	var err error
+
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		if attempt > 0 {
+			delay := backoff(attempt)
+
+			var statusErr *source.StatusError
+			if errors.As(err, &statusErr) && statusErr.RetryAfter > 0 {
+				delay = statusErr.RetryAfter
+			}
+
+			Log.Warn().Err(err).Str("path", path).Int("attempt", attempt).Dur("delay", delay).Msg("Download failed, retrying")
+
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		err = s.fetch(ctx, src, path, r, handle)
+		if err == nil || !source.Retryable(err) {
+			return err
+		}
+	}
+
+	return err
+}
+
+// fetch holds a connection slot of the host while the file is opened and read
+func (s *Scheduler) fetch(ctx context.Context, src source.Source, path string, r *source.Range, handle func(io.Reader) error) error {
+	if s != nil && src.Host() != "" {
+		connections := s.host(src.Host())
+
+		select {
+		case connections <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		defer func() { <-connections }()
+	}
+
+	body, err := src.Open(ctx, path, r)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	return handle(body)
+}
+
+// backoff returns a random delay between half and all of baseBackoff * 2^(attempt-1), capped at maxBackoff
+func backoff(attempt int) time.Duration {
+	limit := min(baseBackoff<<(attempt-1), maxBackoff)
+	return limit/2 + time.Duration(rand.Int63n(int64(limit/2)+1))
+}
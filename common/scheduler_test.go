@@ -0,0 +1,164 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"hstin/zephyr/source"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSchedulerRetryAfter(t *testing.T) {
+	var requests atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		io.WriteString(w, "data")
+	}))
+	defer server.Close()
+
+	start := time.Now()
+
+	var body []byte
+	err := NewScheduler(1, 1).Fetch(context.Background(), source.NewHTTP(server.URL, nil), "file", nil, func(r io.Reader) error {
+		var err error
+		body, err = io.ReadAll(r)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(body) != "data" {
+		t.Errorf("got body %q, want %q", body, "data")
+	}
+	if requests.Load() != 2 {
+		t.Errorf("got %d requests, want 2", requests.Load())
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %v, want the Retry-After of 1s", elapsed)
+	}
+}
+
+func TestSchedulerRetryIncompleteBody(t *testing.T) {
+	var requests atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "4")
+		if requests.Add(1) == 1 {
+			// The connection is closed before the announced length is sent
+			io.WriteString(w, "da")
+			return
+		}
+		io.WriteString(w, "data")
+	}))
+	defer server.Close()
+
+	err := NewScheduler(1, 1).Fetch(context.Background(), source.NewHTTP(server.URL, nil), "file", nil, func(r io.Reader) error {
+		_, err := io.ReadAll(r)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if requests.Load() != 2 {
+		t.Errorf("got %d requests, want 2", requests.Load())
+	}
+}
+
+func TestSchedulerNoRetry(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		handle func(io.Reader) error
+	}{
+		{"not found", http.StatusNotFound, func(io.Reader) error { return nil }},
+		{"decode error", http.StatusOK, func(r io.Reader) error {
+			io.ReadAll(r)
+			return errors.New("invalid file")
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			err := NewScheduler(1, 1).Fetch(context.Background(), source.NewHTTP(server.URL, nil), "file", nil, tt.handle)
+			if err == nil {
+				t.Fatal("got no error")
+			}
+
+			if requests.Load() != 1 {
+				t.Errorf("got %d requests, want 1", requests.Load())
+			}
+		})
+	}
+}
+
+func TestSchedulerHostConcurrency(t *testing.T) {
+	const hostConcurrency = 2
+
+	var active, peak atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := active.Add(1)
+		defer active.Add(-1)
+
+		for {
+			current := peak.Load()
+			if n <= current || peak.CompareAndSwap(current, n) {
+				break
+			}
+		}
+
+		time.Sleep(50 * time.Millisecond)
+		io.WriteString(w, "data")
+	}))
+	defer server.Close()
+
+	scheduler := NewScheduler(8, hostConcurrency)
+	src := source.NewHTTP(server.URL, nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if err := scheduler.Acquire(context.Background()); err != nil {
+				t.Error(err)
+				return
+			}
+			defer scheduler.Release()
+
+			err := scheduler.Fetch(context.Background(), src, "file", nil, func(r io.Reader) error {
+				_, err := io.ReadAll(r)
+				return err
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if peak.Load() != hostConcurrency {
+		t.Errorf("got %d parallel requests to the host, want %d", peak.Load(), hostConcurrency)
+	}
+}
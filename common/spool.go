@@ -0,0 +1,231 @@
+package common
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	. "hstin/zephyr/helper"
+)
+
+// Name of the file in a spool directory that lists the completed steps, one JSON entry per line
+const spoolManifestFileName = "manifest.jsonl"
+
+// SpoolEntry describes a downloaded step in the manifest of a spool
+type SpoolEntry struct {
+	Parameter string `json:"parameter"`
+	Step      int    `json:"step"`
+	File      string `json:"file"`
+	Size      int64  `json:"size"`
+	SHA256    string `json:"sha256"`
+}
+
+type spoolKey struct {
+	parameter string
+	step      int
+}
+
+// Spool keeps the downloaded GRIB files of a single run on disk, so an interrupted download of
+// the same run continues with the steps that are missing. A nil Spool stores nothing.
+type Spool struct {
+	dir      string
+	mu       sync.Mutex
+	manifest *os.File
+	entries  map[spoolKey]SpoolEntry
+}
+
+func spoolDir(tmpPath, model string, run time.Time) string {
+	return path.Join(tmpPath, "spool", model, run.UTC().Format("2006010215"))
+}
+
+// OpenSpool opens the spool of the run and reads the steps that were completed before.
+// Spools of other runs of the model are removed, a download never resumes an older run.
+func OpenSpool(tmpPath, model string, run time.Time) (*Spool, error) {
+	dir := spoolDir(tmpPath, model, run)
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	modelDir := path.Dir(dir)
+	runs, err := os.ReadDir(modelDir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range runs {
+		if r.Name() != path.Base(dir) {
+			Log.Info().Msgf("[%s] Removing spool of run %s", model, r.Name())
+			os.RemoveAll(path.Join(modelDir, r.Name()))
+		}
+	}
+
+	s := &Spool{dir: dir, entries: make(map[spoolKey]SpoolEntry)}
+
+	if err := s.readManifest(); err != nil {
+		return nil, err
+	}
+
+	s.manifest, err = os.OpenFile(path.Join(dir, spoolManifestFileName), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(s.entries) > 0 {
+		Log.Info().Msgf("[%s] Resuming run %s with %d downloaded steps", model, path.Base(dir), len(s.entries))
+	}
+
+	return s, nil
+}
+
+// readManifest loads the entries of the manifest. A line that was cut off by a crash
+// is dropped together with everything after it.
+func (s *Spool) readManifest() error {
+	data, err := os.ReadFile(path.Join(s.dir, spoolManifestFileName))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	valid := 0
+
+	for _, line := range bytes.SplitAfter(data, []byte("\n")) {
+		if !bytes.HasSuffix(line, []byte("\n")) {
+			break
+		}
+
+		var entry SpoolEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			break
+		}
+
+		s.entries[spoolKey{entry.Parameter, entry.Step}] = entry
+		valid += len(line)
+	}
+
+	if valid < len(data) {
+		return os.Truncate(path.Join(s.dir, spoolManifestFileName), int64(valid))
+	}
+
+	return nil
+}
+
+// Get returns the spooled file of the step. Files that are missing or do not match the size
+// and checksum of the manifest are reported as not available.
+func (s *Spool) Get(parameter string, step int) ([]byte, bool) {
+	if s == nil {
+		return nil, false
+	}
+
+	s.mu.Lock()
+	entry, ok := s.entries[spoolKey{parameter, step}]
+	s.mu.Unlock()
+
+	if !ok {
+		return nil, false
+	}
+
+	data, err := os.ReadFile(path.Join(s.dir, entry.File))
+	if err == nil && int64(len(data)) == entry.Size && checksum(data) == entry.SHA256 {
+		return data, true
+	}
+
+	Log.Warn().Msgf("Spooled step %d of %s is damaged, downloading it again", step, parameter)
+
+	s.mu.Lock()
+	delete(s.entries, spoolKey{parameter, step})
+	s.mu.Unlock()
+
+	return nil, false
+}
+
+// Put writes the file of the step to disk and adds it to the manifest
+func (s *Spool) Put(parameter string, step int, data []byte) error {
+	if s == nil {
+		return nil
+	}
+
+	entry := SpoolEntry{
+		Parameter: parameter,
+		Step:      step,
+		File:      fmt.Sprintf("%s_%03d.grib2", parameter, step),
+		Size:      int64(len(data)),
+		SHA256:    checksum(data),
+	}
+
+	fileName := path.Join(s.dir, entry.File)
+
+	if err := writeFileSync(fileName+".tmp", data); err != nil {
+		return err
+	}
+
+	if err := os.Rename(fileName+".tmp", fileName); err != nil {
+		return err
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.manifest.Write(append(line, '\n')); err != nil {
+		return err
+	}
+
+	if err := s.manifest.Sync(); err != nil {
+		return err
+	}
+
+	s.entries[spoolKey{parameter, step}] = entry
+
+	return nil
+}
+
+// Close closes the manifest, the spooled files are kept until RemoveSpool is called
+func (s *Spool) Close() error {
+	if s == nil {
+		return nil
+	}
+
+	return s.manifest.Close()
+}
+
+// RemoveSpool deletes the spool of the run. It is called once the ND files of the run are written.
+func RemoveSpool(tmpPath, model string, run time.Time) error {
+	return os.RemoveAll(spoolDir(tmpPath, model, run))
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func writeFileSync(fileName string, data []byte) error {
+	file, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
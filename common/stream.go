@@ -0,0 +1,219 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"hstin/zephyr/tracing"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hstin-de/ndfile"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	. "hstin/zephyr/helper"
+)
+
+// ParameterStream writes the downloaded GRIB files of a parameter to its ND files while the
+// download is still running. Steps are given in hours and can be added in any order, a step is
+// written as soon as every earlier step of the run was added or skipped, so only steps that
+// arrive out of order are kept in memory.
+//
+// Missing hours between two written steps are filled: instantaneous parameters are interpolated,
+// accumulated and averaged parameters are deaccumulated and the amount of each interval is
+// distributed evenly over its hours. Every field is stored at its validity time, the run time
+// plus the hour of the forecast, since the time of accumulated GRIB fields is the start of the
+// accumulation.
+type ParameterStream struct {
+	name      string
+	parameter ParameterOptions
+	run       time.Time
+	budget    *MemoryBudget
+	span      trace.Span
+
+	// Decodes the GRIB files and stores the hourly fields, replaced in tests
+	decode func([]byte) ndfile.GRIBFile
+	store  func(ndfile.GRIBFile)
+
+	mu      sync.Mutex
+	steps   []int
+	next    int
+	pending map[int][]byte
+	skipped map[int]bool
+	// Set if a step was skipped or could not be decoded
+	incomplete bool
+
+	accumulated   bool
+	accumulator   *Accumulator
+	deaccumulator *Deaccumulator
+	previousGrib  ndfile.GRIBFile
+	previousStep  int
+}
+
+// NewParameterStream returns a stream for the expected steps of the run starting at run. Added
+// steps are held in the budget until they are written.
+func NewParameterStream(ctx context.Context, name string, run time.Time, steps []int, NDFileManager *ndfile.NDFileManager, budget *MemoryBudget) (*ParameterStream, error) {
+	parameter, ok := Parameters[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown parameter '%s'", name)
+	}
+
+	steps = append([]int(nil), steps...)
+	sort.Ints(steps)
+
+	_, span := tracing.Start(ctx, "ProcessParameter",
+		attribute.String("parameter", name),
+		attribute.String("path", NDFileManager.RootPath),
+		attribute.Int("steps", len(steps)),
+	)
+
+	s := &ParameterStream{
+		name:         name,
+		parameter:    parameter,
+		run:          run,
+		budget:       budget,
+		span:         span,
+		decode:       ndfile.ProcessGRIB,
+		store:        func(grib ndfile.GRIBFile) { AddGrib(NDFileManager, parameter, grib) },
+		steps:        steps,
+		pending:      make(map[int][]byte),
+		skipped:      make(map[int]bool),
+		accumulated:  parameter.StepType == ACCUMULATED || parameter.StepType == AVERAGED,
+		previousStep: -1,
+	}
+
+	if s.accumulated {
+		s.accumulator = NewAccumulator(parameter.StepType, parameter.ResetHours)
+		s.deaccumulator = NewDeaccumulator(parameter.Scale)
+	}
+
+	return s, nil
+}
+
+// Add hands over the GRIB file of a step
+func (s *ParameterStream) Add(step int, data []byte) {
+	s.budget.Hold(len(data))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pending[step] = data
+	s.advance()
+}
+
+// Skip marks a step that could not be downloaded, the following steps are written without it
+func (s *ParameterStream) Skip(step int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.skipped[step] = true
+	s.incomplete = true
+	s.advance()
+}
+
+// Close writes the steps that are still waiting for an earlier step, the missing steps are skipped
+func (s *ParameterStream) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	remaining := make([]int, 0, len(s.pending))
+	for step := range s.pending {
+		remaining = append(remaining, step)
+	}
+	sort.Ints(remaining)
+
+	for _, step := range remaining {
+		s.write(step)
+	}
+
+	s.span.End()
+}
+
+// Complete reports whether every expected step was added and written. Steps that are still
+// missing when the stream is closed make it incomplete.
+func (s *ParameterStream) Complete() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return !s.incomplete && s.next == len(s.steps)
+}
+
+// advance writes the expected steps in order until a step is neither added nor skipped
+func (s *ParameterStream) advance() {
+	for ; s.next < len(s.steps); s.next++ {
+		step := s.steps[s.next]
+
+		if s.skipped[step] {
+			delete(s.skipped, step)
+			continue
+		}
+
+		if _, ok := s.pending[step]; !ok {
+			return
+		}
+
+		s.write(step)
+	}
+}
+
+func (s *ParameterStream) write(step int) {
+	data := s.pending[step]
+	delete(s.pending, step)
+	defer s.budget.Release(len(data))
+
+	if step <= s.previousStep {
+		return
+	}
+
+	currentGrib := s.decode(data)
+
+	if currentGrib.DataValues == nil {
+		IngestLog.Warn().Msgf("Could not decode step %d of %s, skipping", step, s.name)
+		s.span.AddEvent("skipped step", trace.WithAttributes(attribute.Int("step", step)))
+		s.incomplete = true
+		return
+	}
+
+	if s.accumulated && s.previousStep == -1 && step > 0 {
+		// Accumulations start at zero, so the first interval is not lost if step 0 is not available
+		s.deaccumulator.Next(make([]float64, len(currentGrib.DataValues)), 0)
+		s.previousStep = 0
+	}
+
+	hours := step - s.previousStep
+
+	if s.accumulated {
+		// Averaged values result in amounts per hour, which equal the mean of each hour
+		amounts := s.deaccumulator.Next(s.accumulator.Total(step, currentGrib.DataValues), hours)
+
+		// Every amount is stored at the end of its hour
+		for h, amount := range amounts {
+			hourGrib := currentGrib
+			hourGrib.DataValues = amount
+			hourGrib.ReferenceTime = s.validTime(s.previousStep + h + 1)
+
+			s.store(hourGrib)
+		}
+	} else {
+		if s.previousStep >= 0 {
+			for h := 1; h < hours; h++ {
+				hourGrib := s.previousGrib
+				hourGrib.DataValues = interpolateValues(s.previousGrib.DataValues, currentGrib.DataValues, h, hours, s.parameter.InterpolationMethod)
+				hourGrib.ReferenceTime = s.validTime(s.previousStep + h)
+
+				s.store(hourGrib)
+			}
+		}
+
+		currentGrib.ReferenceTime = s.validTime(step)
+		s.store(currentGrib)
+	}
+
+	s.previousGrib = currentGrib
+	s.previousStep = step
+}
+
+// validTime returns the time the given hour of the forecast is valid for
+func (s *ParameterStream) validTime(hour int) time.Time {
+	return s.run.Add(time.Duration(hour) * time.Hour)
+}
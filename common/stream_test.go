@@ -0,0 +1,177 @@
+package common
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/hstin-de/ndfile"
+)
+
+var testRun = time.Date(2024, 5, 1, 6, 0, 0, 0, time.UTC)
+
+// newTestStream returns a stream for a single-cell parameter that decodes the first byte of the
+// data as index into values and records the stored fields
+func newTestStream(t *testing.T, options ParameterOptions, steps []int, values []float64, budget *MemoryBudget) (*ParameterStream, *[]ndfile.GRIBFile) {
+	t.Helper()
+
+	ResetParameters()
+	t.Cleanup(ResetParameters)
+
+	if err := RegisterParameter("test", options); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := NewParameterStream(context.Background(), "test", testRun, steps, &ndfile.NDFileManager{}, budget)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var stored []ndfile.GRIBFile
+
+	s.decode = func(data []byte) ndfile.GRIBFile {
+		// Accumulated GRIB fields carry the start of the accumulation instead of the validity time
+		return ndfile.GRIBFile{DataValues: []float64{values[data[0]]}, ReferenceTime: testRun}
+	}
+	s.store = func(grib ndfile.GRIBFile) {
+		stored = append(stored, grib)
+	}
+
+	return s, &stored
+}
+
+func checkStored(t *testing.T, stored []ndfile.GRIBFile, hours []int, values []float64) {
+	t.Helper()
+
+	if len(stored) != len(hours) {
+		t.Fatalf("got %d stored fields, want %d", len(stored), len(hours))
+	}
+
+	for i, grib := range stored {
+		if want := testRun.Add(time.Duration(hours[i]) * time.Hour); !grib.ReferenceTime.Equal(want) {
+			t.Errorf("field %d: stored at %v, want %v", i, grib.ReferenceTime, want)
+		}
+		if math.Abs(grib.DataValues[0]-values[i]) > 1e-9 {
+			t.Errorf("field %d: got %v, want %v", i, grib.DataValues[0], values[i])
+		}
+	}
+}
+
+func TestParameterStreamAccumulatedValidTime(t *testing.T) {
+	steps := []int{0, 1, 2, 3, 6}
+	totals := []float64{0, 0.1, 0.3, 0.3, 0.9}
+
+	s, stored := newTestStream(t, ParameterOptions{StepType: ACCUMULATED}, steps, totals, nil)
+	for i, step := range steps {
+		s.Add(step, []byte{byte(i)})
+	}
+	s.Close()
+
+	checkStored(t, *stored, []int{1, 2, 3, 4, 5, 6}, []float64{0.1, 0.2, 0, 0.2, 0.2, 0.2})
+}
+
+func TestParameterStreamMissingStepZero(t *testing.T) {
+	steps := []int{0, 3, 6}
+	totals := []float64{0, 0.3, 0.6}
+
+	s, stored := newTestStream(t, ParameterOptions{StepType: ACCUMULATED}, steps, totals, nil)
+	s.Skip(0)
+	s.Add(3, []byte{1})
+	s.Add(6, []byte{2})
+	s.Close()
+
+	// The accumulation starts at zero, so the first interval is kept
+	checkStored(t, *stored, []int{1, 2, 3, 4, 5, 6}, []float64{0.1, 0.1, 0.1, 0.1, 0.1, 0.1})
+}
+
+func TestParameterStreamInstantValidTime(t *testing.T) {
+	steps := []int{0, 3}
+	values := []float64{0, 3}
+
+	s, stored := newTestStream(t, ParameterOptions{StepType: INSTANT, InterpolationMethod: LINEAR}, steps, values, nil)
+	s.Add(0, []byte{0})
+	s.Add(3, []byte{1})
+	s.Close()
+
+	checkStored(t, *stored, []int{0, 1, 2, 3}, []float64{0, 1, 2, 3})
+}
+
+// budgetUsed returns the bytes held in the budget
+func budgetUsed(b *MemoryBudget) uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.used
+}
+
+func TestParameterStreamOutOfOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		options ParameterOptions
+		steps   []int
+		values  []float64
+		// Order in which the steps are added
+		order      []int
+		wantHours  []int
+		wantValues []float64
+	}{
+		{
+			name:       "instant",
+			options:    ParameterOptions{StepType: INSTANT, InterpolationMethod: LINEAR},
+			steps:      []int{0, 1, 2, 3},
+			values:     []float64{0, 1, 2, 3},
+			order:      []int{2, 3, 0, 1},
+			wantHours:  []int{0, 1, 2, 3},
+			wantValues: []float64{0, 1, 2, 3},
+		},
+		{
+			name:       "accumulated",
+			options:    ParameterOptions{StepType: ACCUMULATED},
+			steps:      []int{0, 1, 2, 3, 6},
+			values:     []float64{0, 0.1, 0.3, 0.3, 0.9},
+			order:      []int{6, 2, 0, 3, 1},
+			wantHours:  []int{1, 2, 3, 4, 5, 6},
+			wantValues: []float64{0.1, 0.2, 0, 0.2, 0.2, 0.2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			budget := NewMemoryBudget(1 << 20)
+			s, stored := newTestStream(t, tt.options, tt.steps, tt.values, budget)
+
+			index := make(map[int]int, len(tt.steps))
+			for i, step := range tt.steps {
+				index[step] = i
+			}
+
+			first := true
+			for i, step := range tt.order {
+				s.Add(step, []byte{byte(index[step])})
+
+				// Steps added before the first step are held in the budget until it arrives
+				first = first && step != tt.steps[0]
+				if first {
+					if len(*stored) != 0 {
+						t.Errorf("step %d was written before step %d", step, tt.steps[0])
+					}
+					if used := budgetUsed(budget); used != uint64(i+1) {
+						t.Errorf("after step %d: %d bytes held, want %d", step, used, i+1)
+					}
+				}
+			}
+
+			if used := budgetUsed(budget); used != 0 {
+				t.Errorf("%d bytes still held after all steps were added", used)
+			}
+			if !s.Complete() {
+				t.Error("stream is incomplete after all steps were added")
+			}
+
+			s.Close()
+
+			checkStored(t, *stored, tt.wantHours, tt.wantValues)
+		})
+	}
+}
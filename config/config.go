@@ -0,0 +1,264 @@
+package config
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/hex"
+	"fmt"
+	"image/color"
+	"io"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed default.yaml
+var defaultConfig []byte
+
+type Config struct {
+	Paths        PathsConfig                `yaml:"paths"`
+	DefaultModel string                     `yaml:"default_model"`
+	Models       map[string]ModelConfig     `yaml:"models"`
+	Parameters   map[string]ParameterConfig `yaml:"parameters"`
+	Auth         AuthConfig                 `yaml:"auth"`
+}
+
+type PathsConfig struct {
+	// Root folder of the ND files, every model uses a sub folder named after the model
+	Data string `yaml:"data"`
+	// Folder of the CDO grid descriptions and weights
+	Weights string `yaml:"weights"`
+	// Folder for temporary download files
+	Temp string `yaml:"temp"`
+	// Path of the cdo binary
+	Cdo string `yaml:"cdo"`
+}
+
+type ModelConfig struct {
+	// Set to false in a configuration file to remove a built-in model
+	Enabled *bool `yaml:"enabled"`
+	// Provider of the model data, either "dwd" or "noaa"
+	Provider string `yaml:"provider"`
+	// Model used for coordinates, times and values the model can not provide
+	Parent     string   `yaml:"parent"`
+	Priority   int      `yaml:"priority"`
+	Resolution float64  `yaml:"resolution"`
+	Coverage   Coverage `yaml:"coverage"`
+	// Download settings, unset values use the defaults of the provider
+	Download DownloadConfig `yaml:"download"`
+}
+
+type DownloadConfig struct {
+	// Name of the model at the provider, defaults to the name of the model
+	Model                 string      `yaml:"model"`
+	URLFormat             string      `yaml:"url_format"`
+	DeliveryOffsetMinutes int         `yaml:"delivery_offset_minutes"`
+	IntervalHours         int         `yaml:"interval_hours"`
+	MaxStep               map[int]int `yaml:"max_step"`
+	BreakPoint            int         `yaml:"break_point"`
+	// DWD only
+	Grid string `yaml:"grid"`
+	Area string `yaml:"area"`
+	// DWD only, model level read for each height above ground in meters
+	HeightLevels map[int]int `yaml:"height_levels"`
+	// NOAA only
+	Resolution string `yaml:"res"`
+	// Where the files are read from, defaults to the server of the provider
+	Source SourceConfig `yaml:"source"`
+}
+
+type SourceConfig struct {
+	// One of "http" (mirror of the provider), "local" (directory) or "s3"
+	Type string `yaml:"type"`
+	// Base URL of the mirror (http) or endpoint (s3)
+	URL string `yaml:"url"`
+	// Directory with the files in the layout of the provider (local)
+	Path string `yaml:"path"`
+	// S3 only, the credentials default to AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY
+	Bucket    string `yaml:"bucket"`
+	Region    string `yaml:"region"`
+	Prefix    string `yaml:"prefix"`
+	AccessKey string `yaml:"access_key"`
+	SecretKey string `yaml:"secret_key"`
+}
+
+type ParameterConfig struct {
+	// Set to false in a configuration file to remove a built-in parameter
+	Enabled *bool `yaml:"enabled"`
+	// Base name of parameters with levels, defaults to the key of the entry.
+	// Every level is registered as "<name>_<level>", e.g. temperature_850hPa.
+	Name string `yaml:"name"`
+	// GRIB2 product definition of the parameter
+	Discipline    int    `yaml:"discipline"`
+	Category      int    `yaml:"category"`
+	Number        int    `yaml:"number"`
+	Unit          string `yaml:"unit"`
+	Interpolation string `yaml:"interpolation"`
+	// One of "instant", "accumulated" or "averaged" (mean since the start of the forecast or the last reset)
+	StepType string `yaml:"step_type"`
+	// Accumulated and averaged values restart every reset_hours hours, 0 (default) if they run from the start of the forecast
+	ResetHours int `yaml:"reset_hours"`
+	// Values are stored as int16: value = stored * scale + offset, scale defaults to 0.01
+	Scale  float64 `yaml:"scale"`
+	Offset float64 `yaml:"offset"`
+	// One of "single" (default), "pressure" (hPa), "height" (m above ground) or "model" (level number)
+	LevelType string `yaml:"level_type"`
+	Levels    []int  `yaml:"levels"`
+	// Name of the variable per provider or model, e.g. dwd: T_2M
+	Sources map[string]string `yaml:"sources"`
+	// Colors of the map tiles, sorted by value. Values between two stops are interpolated.
+	ColorRamp []ColorStop `yaml:"color_ramp"`
+}
+
+type ColorStop struct {
+	Value float64 `yaml:"value"`
+	// "#rrggbb" or "#rrggbbaa"
+	Color string `yaml:"color"`
+}
+
+// ParseColor parses a color in the form "#rrggbb" or "#rrggbbaa"
+func ParseColor(value string) (color.NRGBA, error) {
+	if !strings.HasPrefix(value, "#") || (len(value) != 7 && len(value) != 9) {
+		return color.NRGBA{}, fmt.Errorf("invalid color '%s', expected #rrggbb or #rrggbbaa", value)
+	}
+
+	components, err := hex.DecodeString(value[1:])
+	if err != nil {
+		return color.NRGBA{}, fmt.Errorf("invalid color '%s', expected #rrggbb or #rrggbbaa", value)
+	}
+
+	c := color.NRGBA{R: components[0], G: components[1], B: components[2], A: 255}
+	if len(components) == 4 {
+		c.A = components[3]
+	}
+
+	return c, nil
+}
+
+type AuthConfig struct {
+	// Requests without a valid API key are rejected if enabled
+	Enabled bool `yaml:"enabled"`
+	// Optional YAML file with a "keys" list in the format of Keys, loaded in addition to Keys
+	KeysFile string         `yaml:"keys_file"`
+	Keys     []APIKeyConfig `yaml:"keys"`
+	// Limits of keys that set none, 0 means unlimited
+	RateLimit  float64 `yaml:"rate_limit"`
+	Burst      int     `yaml:"burst"`
+	DailyQuota int64   `yaml:"daily_quota"`
+}
+
+type APIKeyConfig struct {
+	// Name of the key holder, used in logs and usage counters
+	Name string `yaml:"name"`
+	Key  string `yaml:"key"`
+	// Requests per second, refilled continuously up to Burst requests
+	RateLimit float64 `yaml:"rate_limit"`
+	Burst     int     `yaml:"burst"`
+	// Requests per UTC day
+	DailyQuota int64 `yaml:"daily_quota"`
+}
+
+// LoadKeysFile reads the keys of a keys file
+func LoadKeysFile(path string) ([]APIKeyConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading keys file: %w", err)
+	}
+
+	var file struct {
+		Keys []APIKeyConfig `yaml:"keys"`
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	if err := decoder.Decode(&file); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("parsing keys file %s: %w", path, err)
+	}
+
+	return file.Keys, nil
+}
+
+// Coverage is either the string "global" or a list of [lat, lng] vertices
+type Coverage struct {
+	Global bool
+	Points [][2]float64
+}
+
+func (c *Coverage) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		if value.Value != "global" {
+			return fmt.Errorf("line %d: coverage must be \"global\" or a list of [lat, lng] points", value.Line)
+		}
+		c.Global = true
+		c.Points = nil
+		return nil
+	}
+
+	c.Global = false
+	return value.Decode(&c.Points)
+}
+
+func (c Coverage) MarshalYAML() (interface{}, error) {
+	if c.Global {
+		return "global", nil
+	}
+	return c.Points, nil
+}
+
+// Default returns the built-in configuration
+func Default() (Config, error) {
+	var cfg Config
+	if err := decode(defaultConfig, &cfg); err != nil {
+		return Config{}, fmt.Errorf("parsing default config: %w", err)
+	}
+	return cfg, nil
+}
+
+// Load reads the configuration file at path on top of the built-in configuration. Models and
+// parameters of the file replace the built-in entries with the same name, entries with
+// enabled: false are removed. An empty path returns the built-in configuration.
+func Load(path string) (Config, error) {
+	cfg, err := Default()
+	if err != nil {
+		return Config{}, err
+	}
+
+	if path == "" {
+		return cfg, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("reading config: %w", err)
+	}
+
+	if err := decode(data, &cfg); err != nil {
+		return Config{}, fmt.Errorf("parsing config %s: %w", path, err)
+	}
+
+	for name, model := range cfg.Models {
+		if model.Enabled != nil && !*model.Enabled {
+			delete(cfg.Models, name)
+		}
+	}
+	for name, parameter := range cfg.Parameters {
+		if parameter.Enabled != nil && !*parameter.Enabled {
+			delete(cfg.Parameters, name)
+		}
+	}
+
+	return cfg, nil
+}
+
+func decode(data []byte, cfg *Config) error {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	if err := decoder.Decode(cfg); err != nil && err != io.EOF {
+		return err
+	}
+
+	return nil
+}
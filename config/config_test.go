@@ -0,0 +1,91 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+
+	err := os.WriteFile(path, []byte(`
+models:
+  icon-d2:
+    enabled: false
+  icon-eu:
+    provider: dwd
+    parent: icon
+    priority: 35
+    coverage: global
+parameters:
+  cape:
+    enabled: false
+  visibility:
+    discipline: 0
+    category: 19
+    number: 0
+    unit: m
+    interpolation: linear
+    step_type: instant
+    sources:
+      dwd: VIS
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defaults, err := Default()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := cfg.Models["icon-d2"]; ok {
+		t.Error("disabled model icon-d2 was kept")
+	}
+	if _, ok := cfg.Parameters["cape"]; ok {
+		t.Error("disabled parameter cape was kept")
+	}
+
+	// Entries of the file replace the built-in entry as a whole
+	if model := cfg.Models["icon-eu"]; model.Priority != 35 || !model.Coverage.Global || model.Resolution != 0 {
+		t.Errorf("icon-eu was merged with the built-in entry: %+v", model)
+	}
+
+	if _, ok := cfg.Parameters["visibility"]; !ok {
+		t.Error("new parameter visibility was not added")
+	}
+	if len(cfg.Models) != len(defaults.Models)-1 {
+		t.Errorf("got %d models, want the %d built-in models without icon-d2", len(cfg.Models), len(defaults.Models)-1)
+	}
+	if _, ok := cfg.Models["icon"]; !ok {
+		t.Error("built-in model icon missing")
+	}
+
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("config with a disabled model is invalid: %v", err)
+	}
+}
+
+func TestLoadDisabledParent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+
+	if err := os.WriteFile(path, []byte("models:\n  icon-eu:\n    enabled: false\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// icon-d2 still uses icon-eu as parent
+	if err := cfg.Validate(); err == nil {
+		t.Error("got no error for a removed parent model")
+	}
+}
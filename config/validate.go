@@ -0,0 +1,268 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var providers = map[string]bool{
+	"dwd":  true,
+	"noaa": true,
+}
+
+var interpolationMethods = map[string]bool{
+	"linear": true,
+	"copy":   true,
+}
+
+var levelTypes = map[string]bool{
+	"":         true,
+	"single":   true,
+	"pressure": true,
+	"height":   true,
+	"model":    true,
+}
+
+var sourceTypes = map[string]bool{
+	"":      true,
+	"http":  true,
+	"local": true,
+	"s3":    true,
+}
+
+var stepTypes = map[string]bool{
+	"instant":     true,
+	"accumulated": true,
+	"averaged":    true,
+}
+
+// Validate checks the configuration for missing or inconsistent values and returns all problems found
+func (c Config) Validate() error {
+	var errs []error
+
+	if c.Paths.Data == "" {
+		errs = append(errs, errors.New("paths.data must not be empty"))
+	}
+	if c.Paths.Weights == "" {
+		errs = append(errs, errors.New("paths.weights must not be empty"))
+	}
+	if c.Paths.Temp == "" {
+		errs = append(errs, errors.New("paths.temp must not be empty"))
+	}
+	if c.Paths.Cdo == "" {
+		errs = append(errs, errors.New("paths.cdo must not be empty"))
+	}
+
+	if len(c.Models) == 0 {
+		errs = append(errs, errors.New("no models defined"))
+	}
+
+	if _, ok := c.Models[c.DefaultModel]; !ok {
+		errs = append(errs, fmt.Errorf("default_model '%s' is not defined", c.DefaultModel))
+	}
+
+	for _, name := range sortedKeys(c.Models) {
+		model := c.Models[name]
+
+		if !providers[model.Provider] {
+			errs = append(errs, fmt.Errorf("models.%s: unknown provider '%s'", name, model.Provider))
+		}
+
+		if model.Parent != "" {
+			if _, ok := c.Models[model.Parent]; !ok {
+				errs = append(errs, fmt.Errorf("models.%s: parent model '%s' is not defined", name, model.Parent))
+			}
+		}
+
+		if model.Resolution < 0 {
+			errs = append(errs, fmt.Errorf("models.%s: resolution must not be negative", name))
+		}
+
+		if !model.Coverage.Global {
+			if len(model.Coverage.Points) < 3 {
+				errs = append(errs, fmt.Errorf("models.%s: coverage needs at least 3 points", name))
+			}
+
+			for i, point := range model.Coverage.Points {
+				if point[0] < -90 || point[0] > 90 || point[1] < -180 || point[1] > 180 {
+					errs = append(errs, fmt.Errorf("models.%s: coverage point %d is out of range", name, i))
+				}
+			}
+		}
+
+		download := model.Download
+		if download.DeliveryOffsetMinutes < 0 || download.IntervalHours < 0 || download.BreakPoint < 0 {
+			errs = append(errs, fmt.Errorf("models.%s: download settings must not be negative", name))
+		}
+
+		for hour, maxStep := range download.MaxStep {
+			if hour < 0 || hour > 23 || maxStep < 0 {
+				errs = append(errs, fmt.Errorf("models.%s: invalid max_step entry %d: %d", name, hour, maxStep))
+			}
+		}
+
+		for height, level := range download.HeightLevels {
+			if height <= 0 || level <= 0 {
+				errs = append(errs, fmt.Errorf("models.%s: invalid height_levels entry %d: %d", name, height, level))
+			}
+		}
+
+		switch src := download.Source; {
+		case !sourceTypes[src.Type]:
+			errs = append(errs, fmt.Errorf("models.%s: unknown source type '%s'", name, src.Type))
+		case src.Type == "http" && src.URL == "":
+			errs = append(errs, fmt.Errorf("models.%s: http source requires a url", name))
+		case src.Type == "local" && src.Path == "":
+			errs = append(errs, fmt.Errorf("models.%s: local source requires a path", name))
+		case src.Type == "s3" && (src.URL == "" || src.Bucket == ""):
+			errs = append(errs, fmt.Errorf("models.%s: s3 source requires a url and a bucket", name))
+		}
+	}
+
+	// Detect cycles in the parent chains
+	for _, name := range sortedKeys(c.Models) {
+		visited := map[string]bool{name: true}
+
+		for parent := c.Models[name].Parent; parent != ""; parent = c.Models[parent].Parent {
+			if visited[parent] {
+				errs = append(errs, fmt.Errorf("models.%s: parent chain contains a cycle", name))
+				break
+			}
+			visited[parent] = true
+		}
+	}
+
+	seenCodes := make(map[string]string, len(c.Parameters))
+
+	for _, name := range sortedKeys(c.Parameters) {
+		parameter := c.Parameters[name]
+
+		for _, code := range []int{parameter.Discipline, parameter.Category, parameter.Number} {
+			if code < 0 || code > 255 {
+				errs = append(errs, fmt.Errorf("parameters.%s: GRIB codes must be between 0 and 255", name))
+				break
+			}
+		}
+
+		if !levelTypes[parameter.LevelType] {
+			errs = append(errs, fmt.Errorf("parameters.%s: unknown level_type '%s'", name, parameter.LevelType))
+		}
+
+		levels := parameter.Levels
+
+		if parameter.LevelType == "" || parameter.LevelType == "single" {
+			if len(levels) != 0 {
+				errs = append(errs, fmt.Errorf("parameters.%s: levels require a level_type", name))
+			}
+			levels = []int{0}
+		} else if len(levels) == 0 {
+			errs = append(errs, fmt.Errorf("parameters.%s: level_type '%s' requires levels", name, parameter.LevelType))
+		}
+
+		for _, level := range levels {
+			if level < 0 {
+				errs = append(errs, fmt.Errorf("parameters.%s: level %d must not be negative", name, level))
+			}
+
+			levelType := parameter.LevelType
+			if levelType == "" {
+				levelType = "single"
+			}
+
+			key := fmt.Sprintf("%d/%d/%d/%s/%d", parameter.Discipline, parameter.Category, parameter.Number, levelType, level)
+			if other, ok := seenCodes[key]; ok && other != name {
+				errs = append(errs, fmt.Errorf("parameters.%s: GRIB codes and level %d are already used by '%s'", name, level, other))
+			}
+			seenCodes[key] = name
+		}
+
+		if !interpolationMethods[parameter.Interpolation] {
+			errs = append(errs, fmt.Errorf("parameters.%s: unknown interpolation '%s'", name, parameter.Interpolation))
+		}
+
+		if parameter.Scale < 0 {
+			errs = append(errs, fmt.Errorf("parameters.%s: scale must not be negative", name))
+		}
+
+		if !stepTypes[parameter.StepType] {
+			errs = append(errs, fmt.Errorf("parameters.%s: unknown step_type '%s'", name, parameter.StepType))
+		}
+
+		if parameter.ResetHours < 0 {
+			errs = append(errs, fmt.Errorf("parameters.%s: reset_hours must not be negative", name))
+		} else if parameter.ResetHours > 0 && parameter.StepType != "accumulated" && parameter.StepType != "averaged" {
+			errs = append(errs, fmt.Errorf("parameters.%s: reset_hours requires step_type accumulated or averaged", name))
+		}
+
+		for i, stop := range parameter.ColorRamp {
+			if _, err := ParseColor(stop.Color); err != nil {
+				errs = append(errs, fmt.Errorf("parameters.%s: color_ramp[%d]: %w", name, i, err))
+			}
+
+			if i > 0 && stop.Value <= parameter.ColorRamp[i-1].Value {
+				errs = append(errs, fmt.Errorf("parameters.%s: color_ramp values must be ascending", name))
+			}
+		}
+
+		for source := range parameter.Sources {
+			if _, isModel := c.Models[source]; !providers[source] && !isModel {
+				errs = append(errs, fmt.Errorf("parameters.%s: source '%s' is neither a provider nor a model", name, source))
+			}
+		}
+	}
+
+	errs = append(errs, c.Auth.validate()...)
+
+	return errors.Join(errs...)
+}
+
+func (a AuthConfig) validate() []error {
+	var errs []error
+
+	keys := a.Keys
+
+	if a.KeysFile != "" {
+		fileKeys, err := LoadKeysFile(a.KeysFile)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("auth.keys_file: %w", err))
+		}
+		keys = append(keys, fileKeys...)
+	}
+
+	if a.Enabled && len(keys) == 0 && len(errs) == 0 {
+		errs = append(errs, errors.New("auth: enabled without any keys"))
+	}
+
+	if a.RateLimit < 0 || a.Burst < 0 || a.DailyQuota < 0 {
+		errs = append(errs, errors.New("auth: rate_limit, burst and daily_quota must not be negative"))
+	}
+
+	seenKeys := make(map[string]bool, len(keys))
+
+	for i, key := range keys {
+		if key.Name == "" || key.Key == "" {
+			errs = append(errs, fmt.Errorf("auth.keys[%d]: name and key are required", i))
+		}
+
+		if seenKeys[key.Key] {
+			errs = append(errs, fmt.Errorf("auth.keys[%d]: key of '%s' is used more than once", i, key.Name))
+		}
+		seenKeys[key.Key] = true
+
+		if key.RateLimit < 0 || key.Burst < 0 || key.DailyQuota < 0 {
+			errs = append(errs, fmt.Errorf("auth.keys[%d]: rate_limit, burst and daily_quota must not be negative", i))
+		}
+	}
+
+	return errs
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
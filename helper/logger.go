@@ -1,14 +1,109 @@
 package helper
 
 import (
+	"fmt"
 	"os"
+	"strings"
 
 	"github.com/phuslu/log"
 )
 
-var Log log.Logger = log.Logger{
-	Writer: &log.ConsoleWriter{
+const (
+	LogFormatConsole = "console"
+	LogFormatJSON    = "json"
+)
+
+var Log log.Logger = newLogger("")
+
+// Loggers of the components, every entry carries a "component" field
+var (
+	HTTPLog   log.Logger = newLogger("http")
+	GRPCLog   log.Logger = newLogger("grpc")
+	DWDLog    log.Logger = newLogger("dwd")
+	NOAALog   log.Logger = newLogger("noaa")
+	IngestLog log.Logger = newLogger("ingest")
+)
+
+var componentLoggers = map[string]*log.Logger{
+	"http":   &HTTPLog,
+	"grpc":   &GRPCLog,
+	"dwd":    &DWDLog,
+	"noaa":   &NOAALog,
+	"ingest": &IngestLog,
+}
+
+func newLogger(component string) log.Logger {
+	logger := log.Logger{
+		Level:  log.InfoLevel,
+		Writer: consoleWriter(),
+	}
+
+	if component != "" {
+		logger.Context = log.NewContext(nil).Str("component", component).Value()
+	}
+
+	return logger
+}
+
+func consoleWriter() log.Writer {
+	return &log.ConsoleWriter{
 		Writer:      os.Stdout,
 		ColorOutput: true,
-	},
+	}
+}
+
+// ConfigureLogging sets the output format ("console" or "json") and the level of all loggers.
+// level is a level name, optionally followed by levels of single components, e.g. "info,ingest=debug".
+// It has to be called before the loggers are used.
+func ConfigureLogging(format, level string) error {
+	var writer log.Writer
+
+	switch format {
+	case "", LogFormatConsole:
+		writer = consoleWriter()
+	case LogFormatJSON:
+		writer = log.IOWriter{Writer: os.Stdout}
+	default:
+		return fmt.Errorf("invalid log format '%s', supported formats are console and json", format)
+	}
+
+	levels := map[string]log.Level{"": log.InfoLevel}
+
+	for _, part := range strings.Split(level, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		component, name, found := strings.Cut(part, "=")
+		if !found {
+			component, name = "", part
+		}
+
+		if _, ok := componentLoggers[component]; component != "" && !ok {
+			return fmt.Errorf("invalid log component '%s'", component)
+		}
+
+		parsed := log.ParseLevel(name)
+		if parsed.String() == "????" {
+			return fmt.Errorf("invalid log level '%s'", name)
+		}
+
+		levels[component] = parsed
+	}
+
+	Log.Writer = writer
+	Log.SetLevel(levels[""])
+
+	for component, logger := range componentLoggers {
+		componentLevel, ok := levels[component]
+		if !ok {
+			componentLevel = levels[""]
+		}
+
+		logger.Writer = writer
+		logger.SetLevel(componentLevel)
+	}
+
+	return nil
 }
@@ -1,9 +1,16 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"hstin/zephyr/auth"
+	"hstin/zephyr/common"
+	"hstin/zephyr/config"
 	. "hstin/zephyr/helper"
 	"hstin/zephyr/models/base"
 	"hstin/zephyr/server"
+	"hstin/zephyr/tracing"
 	"os"
 	"sync"
 
@@ -16,6 +23,12 @@ func main() {
 		Name:      "zephyr - A High-Performance Weather API Server",
 		UsageText: "zephyr [global options]",
 		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "config",
+				Aliases: []string{"c"},
+				Usage:   "Path of the configuration file, the built-in configuration is used if not set",
+				EnvVars: []string{"ZEPHYR_CONFIG"},
+			},
 			&cli.BoolFlag{
 				Name:    "http",
 				Value:   false,
@@ -38,9 +51,26 @@ func main() {
 			&cli.BoolFlag{
 				Name:    "fast",
 				Value:   false,
-				Usage:   "Download using multiple threads, uses significantly more memory",
+				Usage:   "Download all parameters in parallel instead of one after another",
 				EnvVars: []string{"FAST_DOWNLOAD"},
 			},
+			&cli.Uint64Flag{
+				Name:    "download-memory",
+				Usage:   "Memory in MiB that downloaded steps waiting to be written may use, downloads are throttled above it (default: half of the available memory)",
+				EnvVars: []string{"DOWNLOAD_MEMORY"},
+			},
+			&cli.IntFlag{
+				Name:    "download-concurrency",
+				Value:   8,
+				Usage:   "Number of files that are downloaded in parallel across all models",
+				EnvVars: []string{"DOWNLOAD_CONCURRENCY"},
+			},
+			&cli.IntFlag{
+				Name:    "download-host-concurrency",
+				Value:   4,
+				Usage:   "Number of parallel requests to a single host",
+				EnvVars: []string{"DOWNLOAD_HOST_CONCURRENCY"},
+			},
 			&cli.StringFlag{
 				Name:    "http-port",
 				Value:   "8081",
@@ -53,6 +83,54 @@ func main() {
 				Usage:   "gRPC server port",
 				EnvVars: []string{"GRPC_PORT"},
 			},
+			&cli.StringFlag{
+				Name:    "http-tls-cert",
+				Usage:   "Certificate file of the HTTP server, serves HTTPS if set",
+				EnvVars: []string{"HTTP_TLS_CERT"},
+			},
+			&cli.StringFlag{
+				Name:    "http-tls-key",
+				Usage:   "Private key file of the HTTP server",
+				EnvVars: []string{"HTTP_TLS_KEY"},
+			},
+			&cli.StringFlag{
+				Name:    "grpc-tls-cert",
+				Usage:   "Certificate file of the gRPC server, serves TLS if set",
+				EnvVars: []string{"GRPC_TLS_CERT"},
+			},
+			&cli.StringFlag{
+				Name:    "grpc-tls-key",
+				Usage:   "Private key file of the gRPC server",
+				EnvVars: []string{"GRPC_TLS_KEY"},
+			},
+			&cli.StringFlag{
+				Name:    "grpc-client-ca",
+				Usage:   "CA file to verify client certificates of the gRPC server against (mTLS)",
+				EnvVars: []string{"GRPC_CLIENT_CA"},
+			},
+			&cli.StringFlag{
+				Name:    "log-format",
+				Value:   LogFormatConsole,
+				Usage:   "Log output format, console or json",
+				EnvVars: []string{"LOG_FORMAT"},
+			},
+			&cli.StringFlag{
+				Name:    "log-level",
+				Value:   "info",
+				Usage:   "Log level, levels of single components can be set with e.g. info,ingest=debug (components: http, grpc, dwd, noaa, ingest)",
+				EnvVars: []string{"LOG_LEVEL"},
+			},
+			&cli.StringFlag{
+				Name:    "otlp-endpoint",
+				Usage:   "OTLP/HTTP endpoint traces are exported to, e.g. http://localhost:4318 (default: tracing disabled)",
+				EnvVars: []string{"OTEL_EXPORTER_OTLP_ENDPOINT"},
+			},
+			&cli.Float64Flag{
+				Name:    "trace-sample-ratio",
+				Value:   1,
+				Usage:   "Fraction of the requests and downloads that are traced",
+				EnvVars: []string{"TRACE_SAMPLE_RATIO"},
+			},
 			&cli.StringSliceFlag{
 				Name:    "models",
 				Value:   cli.NewStringSlice("icon"),
@@ -67,28 +145,111 @@ func main() {
 				EnvVars: []string{"PARAMS"},
 			},
 		},
+		Before: func(cCtx *cli.Context) error {
+			return ConfigureLogging(cCtx.String("log-format"), cCtx.String("log-level"))
+		},
+		Commands: []*cli.Command{
+			{
+				Name:  "config",
+				Usage: "Configuration commands",
+				Subcommands: []*cli.Command{
+					{
+						Name:      "validate",
+						Usage:     "Validate a configuration file",
+						ArgsUsage: "[file]",
+						Action: func(cCtx *cli.Context) error {
+							configPath := cCtx.String("config")
+							if cCtx.Args().Present() {
+								configPath = cCtx.Args().First()
+							}
+
+							cfg, err := config.Load(configPath)
+							if err != nil {
+								return cli.Exit(err.Error(), 1)
+							}
+
+							if err := base.ValidateConfig(cfg); err != nil {
+								return cli.Exit(err.Error(), 1)
+							}
+
+							fmt.Println("Configuration is valid")
+							return nil
+						},
+					},
+				},
+			},
+		},
 		Action: func(cCtx *cli.Context) error {
 
+			cfg, err := config.Load(cCtx.String("config"))
+			if err != nil {
+				return err
+			}
+
+			if err := base.LoadConfig(cfg); err != nil {
+				return err
+			}
+
+			var serverOptions server.ServerOptions
+
+			if cfg.Auth.Enabled {
+				store, err := auth.NewStore(cfg.Auth)
+				if err != nil {
+					return err
+				}
+				serverOptions.Auth = store
+			}
+
+			serverOptions.HTTPTLS, err = tlsOptions(cCtx.String("http-tls-cert"), cCtx.String("http-tls-key"), "")
+			if err != nil {
+				return err
+			}
+
+			serverOptions.GRPCTLS, err = tlsOptions(cCtx.String("grpc-tls-cert"), cCtx.String("grpc-tls-key"), cCtx.String("grpc-client-ca"))
+			if err != nil {
+				return err
+			}
+
+			shutdownTracing, err := tracing.Setup(cCtx.Context, tracing.Options{
+				Endpoint:    cCtx.String("otlp-endpoint"),
+				SampleRatio: cCtx.Float64("trace-sample-ratio"),
+			})
+			if err != nil {
+				return err
+			}
+			defer shutdownTracing(context.Background())
+
 			var wg sync.WaitGroup
 
 			if cCtx.Bool("http") {
 				wg.Add(1)
-				go server.StartServer(cCtx.String("http-port"))
+				go server.StartServer(cCtx.String("http-port"), serverOptions)
 			}
 
 			if cCtx.Bool("grpc") {
 				wg.Add(1)
-				go server.StartGRPCServer(cCtx.String("grpc-port"))
+				go server.StartGRPCServer(cCtx.String("grpc-port"), serverOptions)
 			}
 
 			if cCtx.Bool("download") {
 
+				downloadOptions := common.DownloadOptions{
+					Fast:      cCtx.Bool("fast"),
+					Budget:    common.NewMemoryBudget(cCtx.Uint64("download-memory") << 20),
+					Scheduler: common.NewScheduler(cCtx.Int("download-concurrency"), cCtx.Int("download-host-concurrency")),
+				}
+
 				for _, model := range cCtx.StringSlice("models") {
+					if _, ok := base.AvailableModels[model]; !ok {
+						Log.Warn().Msgf("Model %s not found. skipping...", model)
+						continue
+					}
+
 					wg.Add(1)
 					go func(model string) {
 						defer wg.Done()
 
-						base.AvailableModels[model].Model.DowloadParameter(cCtx.StringSlice("params"), cCtx.Bool("fast"))
+						base.AvailableModels[model].Model.DowloadParameter(cCtx.StringSlice("params"), downloadOptions)
 					}(model)
 				}
 
@@ -103,3 +264,19 @@ func main() {
 		Log.Error().Err(err).Msg("error")
 	}
 }
+
+// tlsOptions returns nil if no certificate is configured
+func tlsOptions(certFile, keyFile, clientCAFile string) (*server.TLSOptions, error) {
+	if certFile == "" && keyFile == "" {
+		if clientCAFile != "" {
+			return nil, errors.New("a client CA requires a TLS certificate and key")
+		}
+		return nil, nil
+	}
+
+	if certFile == "" || keyFile == "" {
+		return nil, errors.New("TLS requires both a certificate and a key file")
+	}
+
+	return &server.TLSOptions{CertFile: certFile, KeyFile: keyFile, ClientCAFile: clientCAFile}, nil
+}
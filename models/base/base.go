@@ -1,80 +1,50 @@
 package base
 
 import (
-	"fmt"
+	"context"
 	"hstin/zephyr/common"
-
-	// . "hstin/zephyr/helper"
-	"hstin/zephyr/models/dwd"
-	"hstin/zephyr/models/noaa"
+	"hstin/zephyr/tracing"
 	"math"
-	"path"
 	"sync"
 	"time"
 
 	"github.com/hstin-de/ndfile"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var cache map[string]ndfile.NDFile = make(map[string]ndfile.NDFile)
+var cacheLock sync.RWMutex
 
 var indexCache map[string]map[int64][2]int = make(map[string]map[int64][2]int, 0)
-
-// Worldwide GFS model
-var gfsModel = noaa.NewGFSModel(noaa.GFSModelOptions{
-	RootPath:  "data",
-	ModelName: "gfs",
-})
-
-// Worldwide ICON model
-var iconModel = dwd.NewIconModel(dwd.IconModelOptions{
-	RootPath:    "data",
-	ModelName:   "icon",
-	ParentModel: gfsModel,
-})
-
-// ICON-EU model
-var iconEUModel = dwd.NewIconModel(dwd.IconModelOptions{
-	RootPath:    "data",
-	ModelName:   "icon-eu",
-	ParentModel: iconModel,
-})
-
-// ICON-D2 model
-var iconD2Model = dwd.NewIconModel(dwd.IconModelOptions{
-	RootPath:    "data",
-	ModelName:   "icon-d2",
-	ParentModel: iconEUModel,
-})
-
-type Border struct {
-	LatMax float64
-	LatMin float64
-	LngMax float64
-	LngMin float64
-}
+var indexLock sync.RWMutex
 
 type ModelOptions struct {
-	Border Border
-	Model  common.BaseModel
+	Model common.BaseModel
+	// Area covered by the model
+	Coverage Polygon
+	// Approximate grid resolution in degrees
+	Resolution float64
+	// Models with a higher priority are preferred during automatic selection
+	Priority int
 }
 
-var AvailableModels = map[string]ModelOptions{
-	"icon":    {Model: iconModel, Border: Border{LatMax: 90, LatMin: -90, LngMax: 180, LngMin: -180}},
-	"icon-eu": {Model: iconEUModel, Border: Border{LatMax: 70.5, LatMin: 29.5, LngMax: 62.5, LngMin: -23.5}},
-	"icon-d2": {Model: iconD2Model, Border: Border{LatMax: 70.5, LatMin: 29.5, LngMax: 62.5, LngMin: -23.5}},
-	"gfs":     {Model: gfsModel, Border: Border{LatMax: 90, LatMin: -90, LngMax: 180, LngMin: -180}},
-}
+// AvailableModels holds all configured models, see LoadConfig
+var AvailableModels = map[string]ModelOptions{}
+
+// Model used if no other model matches the requested coordinates
+var defaultModel string
 
-func GetBestModel(latitude, longitude float64, preferredModel string) (common.BaseModel, string) {
+func GetBestModel(latitude, longitude float64, preferredModel string, startTime time.Time) (common.BaseModel, string) {
 
 	// Check if a preferred model is given and available within the model map and given coordinates
 	if preferredModel != "" && preferredModel != "auto" {
 		if matchedModelOptions, ok := AvailableModels[preferredModel]; ok {
 			choosenModel := matchedModelOptions.Model
-			choosenModelBorder := matchedModelOptions.Border
+			choosenModelOptions := matchedModelOptions
 
 			for {
-				if latitude >= choosenModelBorder.LatMin && latitude <= choosenModelBorder.LatMax && longitude >= choosenModelBorder.LngMin && longitude <= choosenModelBorder.LngMax {
+				if choosenModelOptions.Coverage.Contains(latitude, longitude) && HasData(choosenModel, latitude, longitude, startTime) {
 					return choosenModel, preferredModel
 				}
 
@@ -86,7 +56,7 @@ func GetBestModel(latitude, longitude float64, preferredModel string) (common.Ba
 				preferredModel = parentModel.GetModelName()
 				if parentModelOptions, exists := AvailableModels[preferredModel]; exists {
 					choosenModel = parentModel
-					choosenModelBorder = parentModelOptions.Border
+					choosenModelOptions = parentModelOptions
 				} else {
 					break // If the parent model is not in the map, exit the loop
 				}
@@ -95,25 +65,29 @@ func GetBestModel(latitude, longitude float64, preferredModel string) (common.Ba
 	}
 
 	// No preferred model or no matching preferred model, use the best model for the given coordinates
+	for _, modelName := range modelsByPriority() {
+		modelOptions := AvailableModels[modelName]
 
-	// ICON-EU
-	// Coordinates from: https://dwd-geoportal.de/products/G_D5M/
-	if latitude >= 29.5 && latitude <= 70.5 && longitude >= -23.5 && longitude <= 62.5 {
-		return iconEUModel, "icon-eu"
+		if modelOptions.Coverage.Contains(latitude, longitude) && HasData(modelOptions.Model, latitude, longitude, startTime) {
+			return modelOptions.Model, modelName
+		}
 	}
 
-	// Default to the ICON model
-	return iconModel, "icon"
+	// Default to the configured default model
+	return AvailableModels[defaultModel].Model, defaultModel
 }
 
-func GetNDFile(model common.BaseModel, parameterID, daysSinceEpoch int) (ndfile.NDFile, common.BaseModel, error) {
-	path := path.Join(model.GetRootPath(), fmt.Sprintf("%d_%d.nd", parameterID, daysSinceEpoch))
+func GetNDFile(ctx context.Context, model common.BaseModel, parameter common.ParameterOptions, daysSinceEpoch int) (ndfile.NDFile, common.BaseModel, error) {
+	ctx, span := tracing.Start(ctx, "GetNDFile",
+		attribute.String("model", model.GetModelName()),
+		attribute.String("parameter", parameter.DisplayName),
+		attribute.Int("day", daysSinceEpoch),
+	)
+	defer span.End()
 
-	if cachedFile, ok := cache[path]; ok {
-		return cachedFile, model, nil
-	}
+	path := common.NDFileName(model.GetRootPath(), parameter, daysSinceEpoch)
 
-	ndFile, err := ndfile.PreFetch(path)
+	ndFile, err := openNDFile(ctx, path)
 	if err != nil {
 
 		//check if the model has a parent model. If so, try to get the file from the parent model, if not just continue
@@ -121,63 +95,97 @@ func GetNDFile(model common.BaseModel, parameterID, daysSinceEpoch int) (ndfile.
 			return ndfile.NDFile{}, nil, err
 		}
 
-		return GetNDFile(model.GetParentModel(), parameterID, daysSinceEpoch)
+		return GetNDFile(ctx, model.GetParentModel(), parameter, daysSinceEpoch)
 	}
 
-	cache[path] = ndFile
-
 	return ndFile, model, nil
 }
 
-func GetData(model common.BaseModel, modelName string, parameterID, day, daysSinceEpochStart int, latitude, longitude float64) ([]int16, int, string, error) {
-	ndFile, fetchedModel, err := GetNDFile(model, parameterID, daysSinceEpochStart+day)
-	if err != nil {
-		return nil, 0, "", err
-	}
+func getCellIndex(ndFile ndfile.NDFile, modelName string, latitude, longitude float64) (int, int) {
+	cacheIndex := (int64(latitude/ndFile.Dx) << 32) | (int64(longitude/ndFile.Dy) & 0xFFFFFFFF)
 
-	modelName = fetchedModel.GetModelName()
+	indexLock.RLock()
+	cachedIndex, ok := indexCache[modelName][cacheIndex]
+	indexLock.RUnlock()
 
-	var latIndex int
-	var lngIndex int
+	if ok {
+		return cachedIndex[0], cachedIndex[1]
+	}
 
-	cacheIndex := (int64(latitude/ndFile.Dx) << 32) | (int64(longitude/ndFile.Dy) & 0xFFFFFFFF)
+	latIndex, lngIndex := ndFile.GetIndex(latitude, longitude)
+
+	indexLock.Lock()
+	if indexCache[modelName] == nil {
+		indexCache[modelName] = make(map[int64][2]int, 0)
+	}
 
-	if cachedIndex, ok := indexCache[modelName][cacheIndex]; ok {
-		latIndex = cachedIndex[0]
-		lngIndex = cachedIndex[1]
-	} else {
-		latIndex, lngIndex = ndFile.GetIndex(latitude, longitude)
+	indexCache[modelName][cacheIndex] = [2]int{latIndex, lngIndex}
+	indexLock.Unlock()
 
-		if indexCache[modelName] == nil {
-			indexCache[modelName] = make(map[int64][2]int, 0)
-		}
+	return latIndex, lngIndex
+}
 
-		indexCache[modelName][cacheIndex] = [2]int{latIndex, lngIndex}
+// GetData returns the values of a single day for the given cell together with the name of the
+// model that supplied each step. If the file of the model itself is not available the parent
+// models are used instead. Steps that are missing are filled from the parent models one by one,
+// steps that no model can provide keep the missing value 32767.
+func GetData(ctx context.Context, model common.BaseModel, parameter common.ParameterOptions, day, daysSinceEpochStart int, latitude, longitude float64) ([]int16, []string, int, error) {
+	ndFile, fetchedModel, err := GetNDFile(ctx, model, parameter, daysSinceEpochStart+day)
+	if err != nil {
+		return nil, nil, 0, err
 	}
 
-	values, err := ndFile.GetData(latIndex, lngIndex)
+	modelName := fetchedModel.GetModelName()
+
+	latIndex, lngIndex := getCellIndex(ndFile, modelName, latitude, longitude)
+
+	values, err := readCell(fetchedModel, common.NDFileName(fetchedModel.GetRootPath(), parameter, daysSinceEpochStart+day), ndFile, latIndex, lngIndex)
 	if err != nil {
-		return nil, 0, "", err
+		return nil, nil, 0, err
 	}
 
-	for _, v := range values {
+	timeInterval := int(ndFile.TimeIntervalInMinutes)
+
+	sources := make([]string, len(values))
+	missing := 0
+
+	for j, v := range values {
 		if v == 32767 {
+			missing++
+			continue
+		}
+		sources[j] = modelName
+	}
+
+	for parentModel := fetchedModel.GetParentModel(); parentModel != nil && missing > 0; parentModel = parentModel.GetParentModel() {
+		trace.SpanFromContext(ctx).AddEvent("fallback", trace.WithAttributes(
+			attribute.String("model", parentModel.GetModelName()),
+			attribute.Int("day", daysSinceEpochStart+day),
+			attribute.Int("missing", missing),
+		))
 
-			parentModel := model.GetParentModel()
+		parentValues, parentTimeInterval, err := GetModelData(ctx, parentModel, parameter, daysSinceEpochStart+day, latitude, longitude)
+		if err != nil || parentTimeInterval != timeInterval {
+			continue
+		}
 
-			if parentModel == nil {
+		for j, v := range values {
+			if v != 32767 || parentValues[j] == 32767 {
 				continue
 			}
 
-			return GetData(parentModel, parentModel.GetModelName(), parameterID, day, daysSinceEpochStart, latitude, longitude)
+			values[j] = parentValues[j]
+			sources[j] = parentModel.GetModelName()
+			missing--
 		}
-
 	}
 
-	return values, int(ndFile.TimeIntervalInMinutes), modelName, nil
+	return values, sources, timeInterval, nil
 }
 
-func GetValues(model common.BaseModel, parameter []common.ParameterOptions, startTime time.Time, forecastDays int, latitude, longitude float64) (map[string][]float64, map[string][]float64, map[string][]string, error) {
+// GetValues returns the daily and hourly values for the given parameters, the models that were
+// used per parameter and the time ranges each model supplied
+func GetValues(ctx context.Context, model common.BaseModel, parameter []common.ParameterOptions, startTime time.Time, forecastDays int, latitude, longitude float64) (map[string][]float64, map[string][]float64, map[string][]string, map[string][]ModelRange, error) {
 	daysSinceEpochStart := common.CalculateDaysSinceEpoch(startTime)
 
 	var wg sync.WaitGroup
@@ -185,12 +193,10 @@ func GetValues(model common.BaseModel, parameter []common.ParameterOptions, star
 	// Initialize hourlyData and dailyData maps with initial capacity
 	var hourlyData = make(map[string][]float64, len(parameter))
 	var dailyData = make(map[string][]float64, len(parameter)*2)
+	var usedModels = make(map[string][]string, len(parameter))
+	var modelRanges = make(map[string][]ModelRange, len(parameter))
 
-	var usedModels = make(map[string]map[string]bool, 0)
-
-	var hourlyLock sync.Mutex
-	var dailyLock sync.Mutex
-	var usedModelsLock sync.Mutex
+	var lock sync.Mutex
 
 	// Start concurrent processing for each parameter
 	for _, p := range parameter {
@@ -198,31 +204,35 @@ func GetValues(model common.BaseModel, parameter []common.ParameterOptions, star
 		go func(p common.ParameterOptions) {
 			defer wg.Done()
 
+			ctx, span := tracing.Start(ctx, "GetValues parameter", attribute.String("parameter", p.DisplayName))
+			defer span.End()
+
 			var steps int
+			var timeInterval int
+
+			var hourly []float64
+			var dailyMin, dailyMax []float64
+			var sources []string
 
 			for day := 0; day <= forecastDays; day++ {
 
-				values, timeInterval, modelName, err := GetData(model, model.GetModelName(), p.ParameterID, day, daysSinceEpochStart, latitude, longitude)
+				values, valueSources, interval, err := GetData(ctx, model, p, day, daysSinceEpochStart, latitude, longitude)
 				if err != nil {
 					continue
 				}
 
-				usedModelsLock.Lock()
-				if _, ok := usedModels[p.DisplayName]; !ok {
-					usedModels[p.DisplayName] = make(map[string]bool, 0)
-				}
-
-				usedModels[p.DisplayName][modelName] = true
-				usedModelsLock.Unlock()
-
-				if day == 0 {
+				if hourly == nil {
+					timeInterval = interval
 					steps = (24 * 60) / timeInterval
 
-					hourlyLock.Lock()
-					hourlyData[p.DisplayName] = make([]float64, steps*(forecastDays+1))
-					dailyData[p.DisplayName+"_min"] = make([]float64, (forecastDays + 1))
-					dailyData[p.DisplayName+"_max"] = make([]float64, (forecastDays + 1))
-					hourlyLock.Unlock()
+					hourly = make([]float64, steps*(forecastDays+1))
+					sources = make([]string, steps*(forecastDays+1))
+					dailyMin = make([]float64, (forecastDays + 1))
+					dailyMax = make([]float64, (forecastDays + 1))
+				}
+
+				if interval != timeInterval {
+					continue
 				}
 
 				minValue := math.MaxFloat64
@@ -235,11 +245,10 @@ func GetValues(model common.BaseModel, parameter []common.ParameterOptions, star
 						continue
 					}
 
-					value := float64(v) / 100.0
+					value := p.Decode(v)
 
-					hourlyLock.Lock()
-					hourlyData[p.DisplayName][startIndex+j] = value
-					hourlyLock.Unlock()
+					hourly[startIndex+j] = value
+					sources[startIndex+j] = valueSources[j]
 
 					if value < minValue {
 						minValue = value
@@ -249,24 +258,27 @@ func GetValues(model common.BaseModel, parameter []common.ParameterOptions, star
 					}
 				}
 
-				dailyLock.Lock()
-				dailyData[p.DisplayName+"_min"][day] = minValue
-				dailyData[p.DisplayName+"_max"][day] = maxValue
-				dailyLock.Unlock()
+				dailyMin[day] = minValue
+				dailyMax[day] = maxValue
 			}
-		}(p)
-	}
 
-	wg.Wait()
+			if hourly == nil {
+				return
+			}
 
-	// "param" : ["model1", model2...]
-	var usedModelsMap = make(map[string][]string, 0)
+			ranges, models := buildModelRanges(sources, nil, daysSinceEpochStart, timeInterval)
 
-	for param, models := range usedModels {
-		for model := range models {
-			usedModelsMap[param] = append(usedModelsMap[param], model)
-		}
+			lock.Lock()
+			hourlyData[p.DisplayName] = hourly
+			dailyData[p.DisplayName+"_min"] = dailyMin
+			dailyData[p.DisplayName+"_max"] = dailyMax
+			usedModels[p.DisplayName] = models
+			modelRanges[p.DisplayName] = ranges
+			lock.Unlock()
+		}(p)
 	}
 
-	return dailyData, hourlyData, usedModelsMap, nil
+	wg.Wait()
+
+	return dailyData, hourlyData, usedModels, modelRanges, nil
 }
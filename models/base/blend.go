@@ -0,0 +1,316 @@
+package base
+
+import (
+	"context"
+	"hstin/zephyr/common"
+	"hstin/zephyr/tracing"
+	"math"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ModelRange describes which model supplied a continuous range of a series.
+// From and To are unix timestamps in milliseconds, both inclusive.
+type ModelRange struct {
+	Model       string `json:"model"`
+	BlendedWith string `json:"blended_with,omitempty"`
+	From        int64  `json:"from"`
+	To          int64  `json:"to"`
+}
+
+// GetModelChain returns the model followed by all of its parent models
+func GetModelChain(model common.BaseModel) []common.BaseModel {
+	var chain []common.BaseModel
+
+	for m := model; m != nil; m = m.GetParentModel() {
+		chain = append(chain, m)
+	}
+
+	return chain
+}
+
+// GetModelData reads the values of a single day from the ND files of the model itself,
+// without falling back to any parent model
+func GetModelData(ctx context.Context, model common.BaseModel, parameter common.ParameterOptions, daysSinceEpoch int, latitude, longitude float64) ([]int16, int, error) {
+	path := common.NDFileName(model.GetRootPath(), parameter, daysSinceEpoch)
+
+	ndFile, err := openNDFile(ctx, path)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	latIndex, lngIndex := getCellIndex(ndFile, model.GetModelName(), latitude, longitude)
+
+	values, err := readCell(model, path, ndFile, latIndex, lngIndex)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return values, int(ndFile.TimeIntervalInMinutes), nil
+}
+
+// GetBlendedValues stitches the series of the model and its parents together per time step.
+// Every step is taken from the first model in the chain that has a value for it, so the
+// series continues with the next model as soon as the horizon of the previous one ends.
+// If overlapHours is greater than zero, the last hours of a model are cross-faded into the
+// following model to avoid a hard step in the series. Parents with another time resolution
+// are resampled to the steps of the first model that has data.
+func GetBlendedValues(ctx context.Context, model common.BaseModel, parameter []common.ParameterOptions, startTime time.Time, forecastDays int, latitude, longitude float64, overlapHours int) (map[string][]float64, map[string][]float64, map[string][]string, map[string][]ModelRange, error) {
+	daysSinceEpochStart := common.CalculateDaysSinceEpoch(startTime)
+	chain := GetModelChain(model)
+
+	var wg sync.WaitGroup
+
+	var hourlyData = make(map[string][]float64, len(parameter))
+	var dailyData = make(map[string][]float64, len(parameter)*2)
+	var usedModels = make(map[string][]string, len(parameter))
+	var modelRanges = make(map[string][]ModelRange, len(parameter))
+
+	var lock sync.Mutex
+
+	for _, p := range parameter {
+		wg.Add(1)
+		go func(p common.ParameterOptions) {
+			defer wg.Done()
+
+			ctx, span := tracing.Start(ctx, "GetBlendedValues parameter", attribute.String("parameter", p.DisplayName))
+			defer span.End()
+
+			timeInterval := 0
+			series := make([][]int16, len(chain))
+
+			for i, m := range chain {
+				modelInterval := 0
+				var modelSeries []int16
+
+				for day := 0; day <= forecastDays; day++ {
+					values, interval, err := GetModelData(ctx, m, p, daysSinceEpochStart+day, latitude, longitude)
+					if err != nil {
+						continue
+					}
+
+					if modelSeries == nil {
+						modelInterval = interval
+						modelSeries = missingSeries((forecastDays + 1) * (24 * 60) / interval)
+					}
+
+					// Days of the model written with another time resolution
+					values = resampleSeries(values, interval, modelInterval, p.InterpolationMethod)
+
+					copy(modelSeries[day*len(values):], values)
+				}
+
+				if modelSeries == nil {
+					continue
+				}
+
+				if timeInterval == 0 {
+					timeInterval = modelInterval
+				}
+
+				// Models with another time resolution than the first model are resampled to its steps
+				series[i] = resampleSeries(modelSeries, modelInterval, timeInterval, p.InterpolationMethod)
+			}
+
+			if timeInterval == 0 {
+				return
+			}
+
+			steps := (24 * 60) / timeInterval
+			overlapSteps := (overlapHours * 60) / timeInterval
+
+			if p.InterpolationMethod == common.COPY {
+				overlapSteps = 0
+			}
+
+			hourly, sources, blendSources := stitchSeries(series, p, steps*(forecastDays+1), overlapSteps)
+
+			daily := make([]float64, 2*(forecastDays+1))
+			for day := 0; day <= forecastDays; day++ {
+				minValue := math.MaxFloat64
+				maxValue := -math.MaxFloat64
+
+				for j := day * steps; j < (day+1)*steps; j++ {
+					if sources[j] == -1 {
+						continue
+					}
+
+					if hourly[j] < minValue {
+						minValue = hourly[j]
+					}
+					if hourly[j] > maxValue {
+						maxValue = hourly[j]
+					}
+				}
+
+				daily[day] = minValue
+				daily[forecastDays+1+day] = maxValue
+			}
+
+			sourceNames := make([]string, len(sources))
+			blendNames := make([]string, len(sources))
+
+			for j := range sources {
+				if sources[j] != -1 {
+					sourceNames[j] = chain[sources[j]].GetModelName()
+				}
+				if blendSources[j] != -1 {
+					blendNames[j] = chain[blendSources[j]].GetModelName()
+				}
+			}
+
+			ranges, models := buildModelRanges(sourceNames, blendNames, daysSinceEpochStart, timeInterval)
+
+			lock.Lock()
+			hourlyData[p.DisplayName] = hourly
+			dailyData[p.DisplayName+"_min"] = daily[:forecastDays+1]
+			dailyData[p.DisplayName+"_max"] = daily[forecastDays+1:]
+			usedModels[p.DisplayName] = models
+			modelRanges[p.DisplayName] = ranges
+			lock.Unlock()
+		}(p)
+	}
+
+	wg.Wait()
+
+	return dailyData, hourlyData, usedModels, modelRanges, nil
+}
+
+func missingSeries(length int) []int16 {
+	series := make([]int16, length)
+	for j := range series {
+		series[j] = 32767
+	}
+
+	return series
+}
+
+// resampleSeries converts a series with steps of interval minutes into steps of target minutes.
+// Values between two steps are interpolated linearly, or the previous value is kept for COPY
+// parameters. Steps after the last value or next to a missing value are missing.
+func resampleSeries(values []int16, interval, target int, method common.InterpolationMethod) []int16 {
+	if interval == target {
+		return values
+	}
+
+	resampled := missingSeries(len(values) * interval / target)
+
+	for j := range resampled {
+		minutes := j * target
+		i, offset := minutes/interval, minutes%interval
+
+		if i >= len(values) || values[i] == 32767 {
+			continue
+		}
+
+		if offset == 0 || method == common.COPY {
+			resampled[j] = values[i]
+			continue
+		}
+
+		if i+1 >= len(values) || values[i+1] == 32767 {
+			continue
+		}
+
+		fraction := float64(offset) / float64(interval)
+		resampled[j] = int16(math.Round(float64(values[i]) + fraction*(float64(values[i+1])-float64(values[i]))))
+	}
+
+	return resampled
+}
+
+// buildModelRanges compresses the per step model names into continuous ranges. An empty name
+// marks a step without data. blendedWith is optional and holds the model that was cross-faded
+// into each step. The second return value lists the used models in the order of their first use.
+func buildModelRanges(sources []string, blendedWith []string, daysSinceEpochStart, timeInterval int) ([]ModelRange, []string) {
+	startTs := int64(daysSinceEpochStart) * 86400 * 1000
+	stepLength := int64(timeInterval) * 60 * 1000
+
+	var ranges []ModelRange
+	var models []string
+	seen := make(map[string]bool)
+
+	for j := range sources {
+		if sources[j] == "" {
+			continue
+		}
+
+		blended := ""
+		if blendedWith != nil {
+			blended = blendedWith[j]
+		}
+
+		if !seen[sources[j]] {
+			seen[sources[j]] = true
+			models = append(models, sources[j])
+		}
+
+		timestamp := startTs + int64(j)*stepLength
+
+		if len(ranges) > 0 && j > 0 && sources[j-1] == sources[j] && ranges[len(ranges)-1].BlendedWith == blended && ranges[len(ranges)-1].To == timestamp-stepLength {
+			ranges[len(ranges)-1].To = timestamp
+			continue
+		}
+
+		ranges = append(ranges, ModelRange{
+			Model:       sources[j],
+			BlendedWith: blended,
+			From:        timestamp,
+			To:          timestamp,
+		})
+	}
+
+	return ranges, models
+}
+
+// stitchSeries merges the series of a model chain into one series. For every step the value of
+// the first series that is not missing is used. The returned sources hold the index of the series
+// used for each step (-1 if no series has a value), blendSources the index of the series that was
+// cross-faded into that step (-1 if none).
+func stitchSeries(series [][]int16, parameter common.ParameterOptions, length int, overlapSteps int) ([]float64, []int, []int) {
+	values := make([]float64, length)
+	sources := make([]int, length)
+	blendSources := make([]int, length)
+
+	for j := 0; j < length; j++ {
+		sources[j] = -1
+		blendSources[j] = -1
+
+		for i := range series {
+			if series[i] != nil && series[i][j] != 32767 {
+				sources[j] = i
+				values[j] = parameter.Decode(series[i][j])
+				break
+			}
+		}
+	}
+
+	if overlapSteps <= 0 {
+		return values, sources, blendSources
+	}
+
+	// Cross-fade the end of a model into the model that takes over
+	for j := 1; j < length; j++ {
+		current, next := sources[j-1], sources[j]
+		if current == -1 || next == -1 || next <= current {
+			continue
+		}
+
+		for k := 1; k <= overlapSteps; k++ {
+			step := j - k
+			if step < 0 || sources[step] != current || series[next][step] == 32767 {
+				break
+			}
+
+			weight := float64(overlapSteps-k+1) / float64(overlapSteps+1)
+			nextValue := parameter.Decode(series[next][step])
+
+			values[step] = (1-weight)*values[step] + weight*nextValue
+			blendSources[step] = next
+		}
+	}
+
+	return values, sources, blendSources
+}
@@ -0,0 +1,233 @@
+package base
+
+import (
+	"hstin/zephyr/common"
+	"math"
+	"reflect"
+	"testing"
+)
+
+const missing = 32767
+
+func TestResampleSeries(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   []int16
+		interval int
+		target   int
+		method   common.InterpolationMethod
+		want     []int16
+	}{
+		{
+			name:     "same interval",
+			values:   []int16{1, 2, 3},
+			interval: 60,
+			target:   60,
+			want:     []int16{1, 2, 3},
+		},
+		{
+			name:     "3 hourly to hourly",
+			values:   []int16{0, 300, 600},
+			interval: 180,
+			target:   60,
+			method:   common.LINEAR,
+			want:     []int16{0, 100, 200, 300, 400, 500, 600, missing, missing},
+		},
+		{
+			name:     "3 hourly to hourly copy",
+			values:   []int16{0, 300, 600},
+			interval: 180,
+			target:   60,
+			method:   common.COPY,
+			want:     []int16{0, 0, 0, 300, 300, 300, 600, 600, 600},
+		},
+		{
+			name:     "missing neighbour",
+			values:   []int16{0, missing, 600},
+			interval: 180,
+			target:   60,
+			method:   common.LINEAR,
+			want:     []int16{0, missing, missing, missing, missing, missing, 600, missing, missing},
+		},
+		{
+			name:     "int16 range",
+			values:   []int16{-30000, 30000},
+			interval: 120,
+			target:   60,
+			method:   common.LINEAR,
+			want:     []int16{-30000, 0, 30000, missing},
+		},
+		{
+			name:     "15 minutes to hourly",
+			values:   []int16{0, 1, 2, 3, 4, 5, 6, 7},
+			interval: 15,
+			target:   60,
+			method:   common.LINEAR,
+			want:     []int16{0, 4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resampleSeries(tt.values, tt.interval, tt.target, tt.method); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStitchSeries(t *testing.T) {
+	parameter := common.ParameterOptions{InterpolationMethod: common.LINEAR}
+
+	tests := []struct {
+		name         string
+		series       [][]int16
+		overlapSteps int
+		want         []float64
+		wantSources  []int
+		wantBlend    []int
+	}{
+		{
+			name: "horizon boundary",
+			series: [][]int16{
+				{1000, 1000, 1000, 1000, 1000, 1000, missing, missing},
+				{4000, 4000, 4000, 4000, 4000, 4000, 4000, 4000},
+			},
+			want:        []float64{10, 10, 10, 10, 10, 10, 40, 40},
+			wantSources: []int{0, 0, 0, 0, 0, 0, 1, 1},
+			wantBlend:   []int{-1, -1, -1, -1, -1, -1, -1, -1},
+		},
+		{
+			name: "overlap at the horizon boundary",
+			series: [][]int16{
+				{1000, 1000, 1000, 1000, 1000, 1000, missing, missing},
+				{4000, 4000, 4000, 4000, 4000, 4000, 4000, 4000},
+			},
+			overlapSteps: 2,
+			// The weight of the following model grows by 1/3 per step towards the boundary
+			want:        []float64{10, 10, 10, 10, 20, 30, 40, 40},
+			wantSources: []int{0, 0, 0, 0, 0, 0, 1, 1},
+			wantBlend:   []int{-1, -1, -1, -1, 1, 1, -1, -1},
+		},
+		{
+			name: "overlap longer than the model",
+			series: [][]int16{
+				{1000, missing, missing},
+				{4000, 4000, 4000},
+			},
+			overlapSteps: 3,
+			want:         []float64{10*0.25 + 40*0.75, 40, 40},
+			wantSources:  []int{0, 1, 1},
+			wantBlend:    []int{1, -1, -1},
+		},
+		{
+			name: "gap filled by the parent",
+			series: [][]int16{
+				{1000, 1000, 1000, missing, missing, 1000, 1000},
+				{4000, 4000, 4000, 4000, 4000, 4000, 4000},
+			},
+			overlapSteps: 2,
+			// Only the end of the first model is cross-faded, it takes over again without a fade
+			want:        []float64{10, 20, 30, 40, 40, 10, 10},
+			wantSources: []int{0, 0, 0, 1, 1, 0, 0},
+			wantBlend:   []int{-1, 1, 1, -1, -1, -1, -1},
+		},
+		{
+			name: "gap without data",
+			series: [][]int16{
+				{1000, missing, missing, 1000},
+				nil,
+				{missing, missing, 4000, 4000},
+			},
+			overlapSteps: 1,
+			want:         []float64{10, 0, 40, 10},
+			wantSources:  []int{0, -1, 2, 0},
+			wantBlend:    []int{-1, -1, -1, -1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, sources, blendSources := stitchSeries(tt.series, parameter, len(tt.want), tt.overlapSteps)
+
+			for j := range tt.want {
+				if math.Abs(values[j]-tt.want[j]) > 1e-9 {
+					t.Errorf("step %d: got %v, want %v", j, values[j], tt.want[j])
+				}
+			}
+			if !reflect.DeepEqual(sources, tt.wantSources) {
+				t.Errorf("got sources %v, want %v", sources, tt.wantSources)
+			}
+			if !reflect.DeepEqual(blendSources, tt.wantBlend) {
+				t.Errorf("got blend sources %v, want %v", blendSources, tt.wantBlend)
+			}
+		})
+	}
+}
+
+func TestBuildModelRanges(t *testing.T) {
+	const day = 19800
+	const hour = int64(3600 * 1000)
+	start := int64(day) * 86400 * 1000
+
+	tests := []struct {
+		name        string
+		sources     []string
+		blendedWith []string
+		want        []ModelRange
+		wantModels  []string
+	}{
+		{
+			name:    "horizon boundary",
+			sources: []string{"icon-d2", "icon-d2", "icon-d2", "icon", "icon"},
+			want: []ModelRange{
+				{Model: "icon-d2", From: start, To: start + 2*hour},
+				{Model: "icon", From: start + 3*hour, To: start + 4*hour},
+			},
+			wantModels: []string{"icon-d2", "icon"},
+		},
+		{
+			name:        "overlap at the horizon boundary",
+			sources:     []string{"icon-d2", "icon-d2", "icon-d2", "icon", "icon"},
+			blendedWith: []string{"", "icon", "icon", "", ""},
+			want: []ModelRange{
+				{Model: "icon-d2", From: start, To: start},
+				{Model: "icon-d2", BlendedWith: "icon", From: start + hour, To: start + 2*hour},
+				{Model: "icon", From: start + 3*hour, To: start + 4*hour},
+			},
+			wantModels: []string{"icon-d2", "icon"},
+		},
+		{
+			name:    "gap filled by the parent",
+			sources: []string{"icon-d2", "icon", "icon-d2", "icon-d2"},
+			want: []ModelRange{
+				{Model: "icon-d2", From: start, To: start},
+				{Model: "icon", From: start + hour, To: start + hour},
+				{Model: "icon-d2", From: start + 2*hour, To: start + 3*hour},
+			},
+			wantModels: []string{"icon-d2", "icon"},
+		},
+		{
+			name:    "gap without data",
+			sources: []string{"icon", "", "icon"},
+			want: []ModelRange{
+				{Model: "icon", From: start, To: start},
+				{Model: "icon", From: start + 2*hour, To: start + 2*hour},
+			},
+			wantModels: []string{"icon"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ranges, models := buildModelRanges(tt.sources, tt.blendedWith, day, 60)
+
+			if !reflect.DeepEqual(ranges, tt.want) {
+				t.Errorf("got ranges %+v, want %+v", ranges, tt.want)
+			}
+			if !reflect.DeepEqual(models, tt.wantModels) {
+				t.Errorf("got models %v, want %v", models, tt.wantModels)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package base
+
+import (
+	"context"
+	"fmt"
+	lru "hstin/zephyr/cache"
+	"hstin/zephyr/common"
+	"time"
+
+	"github.com/hstin-de/ndfile"
+	"golang.org/x/sync/singleflight"
+)
+
+// Number of cells of a single day and parameter kept in memory, about 30 MB
+const cellCacheEntries = 128 * 1024
+
+// Values of recently read cells, keyed by ND file, cell and the ingestion of the model, so a new
+// run is read from disk again
+var cellCache = lru.NewLRU[[]int16](cellCacheEntries, time.Hour)
+
+// Concurrent reads of the same cell share a single read
+var cellReads singleflight.Group
+
+// GridCell is the cell of a model grid that contains the requested coordinates
+type GridCell struct {
+	Model string `json:"model"`
+	// Center of the cell
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// readCell returns the values of a cell of the ND file of the model. The returned slice is a copy
+// and may be modified by the caller.
+func readCell(model common.BaseModel, path string, ndFile ndfile.NDFile, latIndex, lngIndex int) ([]int16, error) {
+	run, _ := LatestRun(model)
+	key := fmt.Sprintf("%s|%d|%d|%d", path, latIndex, lngIndex, run.Ingested.UnixNano())
+
+	values, ok := cellCache.Get(key)
+	if !ok {
+		result, err, _ := cellReads.Do(key, func() (interface{}, error) {
+			values, err := ndFile.GetData(latIndex, lngIndex)
+			if err != nil {
+				return nil, err
+			}
+
+			cellCache.Add(key, values)
+			return values, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		values = result.([]int16)
+	}
+
+	return append([]int16(nil), values...), nil
+}
+
+// GetGridCell returns the cell of the ND file of the parameter that contains the coordinates.
+// If the model has no file for the day, the cell of the parent model supplying the data is returned.
+func GetGridCell(ctx context.Context, model common.BaseModel, parameter common.ParameterOptions, daysSinceEpoch int, latitude, longitude float64) (GridCell, error) {
+	ndFile, fetchedModel, err := GetNDFile(ctx, model, parameter, daysSinceEpoch)
+	if err != nil {
+		return GridCell{}, err
+	}
+
+	latIndex, lngIndex := getCellIndex(ndFile, fetchedModel.GetModelName(), latitude, longitude)
+	if latIndex < 0 || latIndex >= len(ndFile.DistinctLatitudes) || lngIndex < 0 || lngIndex >= len(ndFile.DistinctLongitudes) {
+		return GridCell{}, fmt.Errorf("coordinates outside the grid of %s", fetchedModel.GetModelName())
+	}
+
+	return GridCell{
+		Model:     fetchedModel.GetModelName(),
+		Latitude:  ndFile.DistinctLatitudes[latIndex],
+		Longitude: normalizeLongitude(ndFile.DistinctLongitudes[lngIndex]),
+	}, nil
+}
@@ -0,0 +1,268 @@
+package base
+
+import (
+	"errors"
+	"fmt"
+	"hstin/zephyr/common"
+	"hstin/zephyr/config"
+	"hstin/zephyr/models/dwd"
+	"hstin/zephyr/models/noaa"
+	"hstin/zephyr/source"
+)
+
+var interpolationMethods = map[string]common.InterpolationMethod{
+	"linear": common.LINEAR,
+	"copy":   common.COPY,
+}
+
+var levelTypes = map[string]common.LevelType{
+	"":         common.SINGLE_LEVEL,
+	"single":   common.SINGLE_LEVEL,
+	"pressure": common.PRESSURE_LEVEL,
+	"height":   common.HEIGHT_LEVEL,
+	"model":    common.MODEL_LEVEL,
+}
+
+// Level types that are not available from a provider
+var unsupportedLevelTypes = map[string]map[string]bool{
+	"noaa": {"model": true},
+}
+
+var stepTypes = map[string]common.StepType{
+	"instant":     common.INSTANT,
+	"accumulated": common.ACCUMULATED,
+	"averaged":    common.AVERAGED,
+}
+
+// ValidateConfig checks the configuration including the download settings of the providers
+func ValidateConfig(cfg config.Config) error {
+	var errs []error
+
+	if err := cfg.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+
+	for name, modelConfig := range cfg.Models {
+		remoteModel := modelConfig.Download.Model
+		if remoteModel == "" {
+			remoteModel = name
+		}
+
+		if modelConfig.Download.URLFormat != "" {
+			if modelConfig.Provider == "dwd" {
+				_, pathFormat, _ := source.SplitURL(modelConfig.Download.URLFormat)
+				if _, err := dwd.CheckURLFormat(pathFormat); err != nil {
+					errs = append(errs, fmt.Errorf("models.%s: %w", name, err))
+				}
+			}
+			continue
+		}
+
+		switch modelConfig.Provider {
+		case "dwd":
+			if !dwd.HasModel(remoteModel) {
+				errs = append(errs, fmt.Errorf("models.%s: unknown DWD model '%s', url_format is required", name, remoteModel))
+			}
+		case "noaa":
+			if !noaa.HasModel(remoteModel) {
+				errs = append(errs, fmt.Errorf("models.%s: unknown NOAA model '%s', url_format is required", name, remoteModel))
+			}
+		}
+	}
+
+	for name, parameterConfig := range cfg.Parameters {
+		for source := range parameterConfig.Sources {
+			provider := source
+			if modelConfig, ok := cfg.Models[source]; ok {
+				provider = modelConfig.Provider
+			}
+
+			if unsupportedLevelTypes[provider][parameterConfig.LevelType] {
+				errs = append(errs, fmt.Errorf("parameters.%s: level_type '%s' is not available from %s", name, parameterConfig.LevelType, source))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// expandParameter returns the names and options of all parameters described by one configuration entry
+func expandParameter(key string, parameterConfig config.ParameterConfig) map[string]common.ParameterOptions {
+	options := common.ParameterOptions{
+		Unit:                parameterConfig.Unit,
+		InterpolationMethod: interpolationMethods[parameterConfig.Interpolation],
+		StepType:            stepTypes[parameterConfig.StepType],
+		Scale:               parameterConfig.Scale,
+		Offset:              parameterConfig.Offset,
+		ResetHours:          parameterConfig.ResetHours,
+		Discipline:          parameterConfig.Discipline,
+		Category:            parameterConfig.Category,
+		Number:              parameterConfig.Number,
+		Sources:             parameterConfig.Sources,
+	}
+
+	for _, stop := range parameterConfig.ColorRamp {
+		// Colors are checked by ValidateConfig
+		c, _ := config.ParseColor(stop.Color)
+		options.ColorRamp = append(options.ColorRamp, common.ColorStop{Value: stop.Value, Color: c})
+	}
+
+	levelType := levelTypes[parameterConfig.LevelType]
+	if levelType == common.SINGLE_LEVEL {
+		return map[string]common.ParameterOptions{key: options}
+	}
+
+	name := parameterConfig.Name
+	if name == "" {
+		name = key
+	}
+
+	expanded := make(map[string]common.ParameterOptions, len(parameterConfig.Levels))
+
+	for _, value := range parameterConfig.Levels {
+		options.Level = common.Level{Type: levelType, Value: value}
+		expanded[name+"_"+options.Level.String()] = options
+	}
+
+	return expanded
+}
+
+// newSource returns nil if no source is configured, the downloaders use the server of the provider then
+func newSource(cfg config.SourceConfig) (source.Source, error) {
+	if cfg.Type == "" {
+		return nil, nil
+	}
+
+	return source.New(source.Options{
+		Type:      cfg.Type,
+		URL:       cfg.URL,
+		Path:      cfg.Path,
+		Bucket:    cfg.Bucket,
+		Region:    cfg.Region,
+		Prefix:    cfg.Prefix,
+		AccessKey: cfg.AccessKey,
+		SecretKey: cfg.SecretKey,
+	})
+}
+
+// LoadConfig registers the parameters and creates all models of the configuration
+func LoadConfig(cfg config.Config) error {
+	if err := ValidateConfig(cfg); err != nil {
+		return err
+	}
+
+	common.ResetParameters()
+
+	for key, parameterConfig := range cfg.Parameters {
+		for name, options := range expandParameter(key, parameterConfig) {
+			if err := common.RegisterParameter(name, options); err != nil {
+				return err
+			}
+		}
+	}
+
+	availableModels := make(map[string]ModelOptions, len(cfg.Models))
+
+	var createModel func(name string) (common.BaseModel, error)
+	createModel = func(name string) (common.BaseModel, error) {
+		if modelOptions, ok := availableModels[name]; ok {
+			return modelOptions.Model, nil
+		}
+
+		modelConfig := cfg.Models[name]
+
+		var parentModel common.BaseModel
+		if modelConfig.Parent != "" {
+			var err error
+			if parentModel, err = createModel(modelConfig.Parent); err != nil {
+				return nil, err
+			}
+		}
+
+		download := modelConfig.Download
+
+		src, err := newSource(download.Source)
+		if err != nil {
+			return nil, fmt.Errorf("models.%s: %w", name, err)
+		}
+
+		var model common.BaseModel
+
+		switch modelConfig.Provider {
+		case "dwd":
+			err = dwd.RegisterModel(name, dwd.DWDModelOptions{
+				Model:                         download.Model,
+				URLFormat:                     download.URLFormat,
+				OpenDataDeliveryOffsetMinutes: download.DeliveryOffsetMinutes,
+				IntervalHours:                 download.IntervalHours,
+				Grid:                          download.Grid,
+				Area:                          download.Area,
+				MaxStep:                       download.MaxStep,
+				BreakPoint:                    download.BreakPoint,
+				HeightLevels:                  download.HeightLevels,
+				Source:                        src,
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			model = dwd.NewIconModel(dwd.IconModelOptions{
+				RootPath:    cfg.Paths.Data,
+				ModelName:   name,
+				ParentModel: parentModel,
+				WeightsPath: cfg.Paths.Weights,
+				TmpPath:     cfg.Paths.Temp,
+				CdoPath:     cfg.Paths.Cdo,
+			})
+		case "noaa":
+			err = noaa.RegisterModel(name, noaa.NOAAModelOptions{
+				Model:                         download.Model,
+				URLFormat:                     download.URLFormat,
+				OpenDataDeliveryOffsetMinutes: download.DeliveryOffsetMinutes,
+				IntervalHours:                 download.IntervalHours,
+				Res:                           download.Resolution,
+				MaxStep:                       download.MaxStep,
+				BreakPoint:                    download.BreakPoint,
+				Source:                        src,
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			model = noaa.NewGFSModel(noaa.GFSModelOptions{
+				RootPath:    cfg.Paths.Data,
+				ModelName:   name,
+				ParentModel: parentModel,
+				TmpPath:     cfg.Paths.Temp,
+			})
+		}
+
+		coverage := GlobalCoverage
+		if !modelConfig.Coverage.Global {
+			coverage = make(Polygon, len(modelConfig.Coverage.Points))
+			for i, point := range modelConfig.Coverage.Points {
+				coverage[i] = Point{Lat: point[0], Lng: point[1]}
+			}
+		}
+
+		availableModels[name] = ModelOptions{
+			Model:      model,
+			Coverage:   coverage,
+			Resolution: modelConfig.Resolution,
+			Priority:   modelConfig.Priority,
+		}
+
+		return model, nil
+	}
+
+	for name := range cfg.Models {
+		if _, err := createModel(name); err != nil {
+			return err
+		}
+	}
+
+	AvailableModels = availableModels
+	defaultModel = cfg.DefaultModel
+
+	return nil
+}
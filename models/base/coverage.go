@@ -0,0 +1,95 @@
+package base
+
+// Point is a single vertex of a coverage polygon
+type Point struct {
+	Lat float64
+	Lng float64
+}
+
+// Polygon describes the area a model covers. Vertices are given in order and
+// the polygon is closed implicitly between the last and the first vertex.
+type Polygon []Point
+
+// GlobalCoverage covers the whole earth
+var GlobalCoverage = Polygon{
+	{Lat: -90, Lng: -180},
+	{Lat: -90, Lng: 180},
+	{Lat: 90, Lng: 180},
+	{Lat: 90, Lng: -180},
+}
+
+// Contains reports whether the given coordinates lie inside or on the border of the polygon.
+// An edge between two vertices more than 180° of longitude apart crosses the antimeridian,
+// e.g. from 170° to -170°. Edges spanning exactly 360°, as along the poles of GlobalCoverage,
+// follow the latitude around the earth.
+func (p Polygon) Contains(latitude, longitude float64) bool {
+	if len(p) < 3 {
+		return false
+	}
+
+	polygon, crosses := p.unwrap()
+	if !crosses {
+		return polygon.contains(latitude, longitude)
+	}
+
+	// The unwrapped longitudes of the polygon extend beyond ±180°
+	return polygon.contains(latitude, longitude) ||
+		polygon.contains(latitude, longitude+360) ||
+		polygon.contains(latitude, longitude-360)
+}
+
+// unwrap shifts the longitudes of the vertices by multiples of 360° so that no edge crosses the
+// antimeridian, crosses is true if a vertex was shifted
+func (p Polygon) unwrap() (polygon Polygon, crosses bool) {
+	polygon = make(Polygon, len(p))
+	polygon[0] = p[0]
+
+	shift := 0.0
+	for i := 1; i < len(p); i++ {
+		delta := p[i].Lng - p[i-1].Lng
+
+		switch {
+		case delta > 180 && delta < 360:
+			shift -= 360
+		case delta < -180 && delta > -360:
+			shift += 360
+		}
+
+		polygon[i] = Point{Lat: p[i].Lat, Lng: p[i].Lng + shift}
+		crosses = crosses || shift != 0
+	}
+
+	return polygon, crosses
+}
+
+// contains casts a ray along the longitude axis
+func (p Polygon) contains(latitude, longitude float64) bool {
+	inside := false
+
+	for i, j := 0, len(p)-1; i < len(p); j, i = i, i+1 {
+		a, b := p[i], p[j]
+
+		if onSegment(a, b, latitude, longitude) {
+			return true
+		}
+
+		if (a.Lat > latitude) != (b.Lat > latitude) {
+			intersection := a.Lng + (latitude-a.Lat)*(b.Lng-a.Lng)/(b.Lat-a.Lat)
+			if longitude < intersection {
+				inside = !inside
+			}
+		}
+	}
+
+	return inside
+}
+
+func onSegment(a, b Point, latitude, longitude float64) bool {
+	cross := (b.Lng-a.Lng)*(latitude-a.Lat) - (b.Lat-a.Lat)*(longitude-a.Lng)
+	if cross > 1e-9 || cross < -1e-9 {
+		return false
+	}
+
+	return longitude >= min(a.Lng, b.Lng) && longitude <= max(a.Lng, b.Lng) &&
+		latitude >= min(a.Lat, b.Lat) && latitude <= max(a.Lat, b.Lat)
+}
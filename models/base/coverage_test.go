@@ -0,0 +1,78 @@
+package base
+
+import "testing"
+
+func TestPolygonContains(t *testing.T) {
+	// Roughly the domain of icon-eu
+	europe := Polygon{
+		{Lat: 29.5, Lng: -23.5},
+		{Lat: 29.5, Lng: 62.5},
+		{Lat: 70.5, Lng: 62.5},
+		{Lat: 70.5, Lng: -23.5},
+	}
+
+	triangle := Polygon{
+		{Lat: 0, Lng: 0},
+		{Lat: 0, Lng: 10},
+		{Lat: 10, Lng: 5},
+	}
+
+	// The Bering Sea, from Kamchatka to Alaska across the antimeridian
+	bering := Polygon{
+		{Lat: 50, Lng: 160},
+		{Lat: 50, Lng: -160},
+		{Lat: 66, Lng: -160},
+		{Lat: 66, Lng: 160},
+	}
+
+	// The same area with a vertex on each side and the antimeridian crossed twice
+	beringZigZag := Polygon{
+		{Lat: 50, Lng: 160},
+		{Lat: 50, Lng: -160},
+		{Lat: 58, Lng: 170},
+		{Lat: 66, Lng: -160},
+		{Lat: 66, Lng: 160},
+	}
+
+	tests := []struct {
+		name      string
+		polygon   Polygon
+		latitude  float64
+		longitude float64
+		want      bool
+	}{
+		{"inside", europe, 52.5, 13.4, true},
+		{"outside east", europe, 52.5, 80, false},
+		{"outside south", europe, 10, 13.4, false},
+		{"on a horizontal edge", europe, 29.5, 0, true},
+		{"on a vertical edge", europe, 50, -23.5, true},
+		{"on a vertex", europe, 70.5, 62.5, true},
+		{"inside triangle", triangle, 2, 5, true},
+		{"on a sloped edge", triangle, 5, 2.5, true},
+		{"on the top vertex", triangle, 10, 5, true},
+		{"beside the top vertex", triangle, 10, 5.01, false},
+		{"next to a sloped edge", triangle, 5, 2.4, false},
+		{"too few points", Polygon{{0, 0}, {1, 1}}, 0, 0, false},
+		{"global", GlobalCoverage, -33.9, 151.2, true},
+		{"global on the antimeridian", GlobalCoverage, 0, 180, true},
+		{"global at the pole", GlobalCoverage, 90, 0, true},
+		{"across the antimeridian, west of it", bering, 58, 175, true},
+		{"across the antimeridian, east of it", bering, 58, -175, true},
+		{"across the antimeridian, on it", bering, 58, 180, true},
+		{"across the antimeridian, on it from the west", bering, 58, -180, true},
+		{"across the antimeridian, outside west", bering, 58, 150, false},
+		{"across the antimeridian, outside east", bering, 58, -150, false},
+		{"across the antimeridian, not the other side of the earth", bering, 58, 0, false},
+		{"across the antimeridian, on the crossing edge", bering, 50, -175, true},
+		{"crossing twice, inside", beringZigZag, 54, 179, true},
+		{"crossing twice, outside the notch", beringZigZag, 58, -165, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.polygon.Contains(tt.latitude, tt.longitude); got != tt.want {
+				t.Errorf("Contains(%v, %v) = %t, want %t", tt.latitude, tt.longitude, got, tt.want)
+			}
+		})
+	}
+}
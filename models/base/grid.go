@@ -0,0 +1,245 @@
+package base
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hstin/zephyr/common"
+	"math"
+	"time"
+)
+
+// Maximum number of cells of a grid after applying the stride
+const MaxGridCells = 4 * 1024 * 1024
+
+// BoundingBox in degrees, West may be greater than East if the box crosses the antimeridian
+type BoundingBox struct {
+	South float64
+	West  float64
+	North float64
+	East  float64
+}
+
+func (b BoundingBox) Validate() error {
+	if b.South < -90 || b.North > 90 || b.South > b.North {
+		return errors.New("invalid latitudes")
+	}
+
+	if b.West < -180 || b.West > 180 || b.East < -180 || b.East > 180 {
+		return errors.New("invalid longitudes")
+	}
+
+	return nil
+}
+
+func (b BoundingBox) containsLongitude(longitude float64) bool {
+	if b.West <= b.East {
+		return longitude >= b.West && longitude <= b.East
+	}
+	return longitude >= b.West || longitude <= b.East
+}
+
+// Center returns the center of the box
+func (b BoundingBox) Center() (float64, float64) {
+	longitude := (b.West + b.East) / 2
+	if b.West > b.East {
+		longitude = normalizeLongitude(longitude + 180)
+	}
+	return (b.South + b.North) / 2, longitude
+}
+
+// Grid is the field of a parameter at a single time step. Values are stored row by row from
+// south to north, every row from west to east, starting at the cell at Latitudes[0], Longitudes[0].
+type Grid struct {
+	Model     string
+	Parameter string
+	Unit      string
+	// Unix timestamp of the step in milliseconds
+	Time       int64
+	Latitudes  []float64
+	Longitudes []float64
+	// Distance between the returned rows and columns in degrees
+	Dx float64
+	Dy float64
+	// NaN for missing values
+	Values []float32
+	// One bit per value in the order of Values, least significant bit first, set if the value is present
+	Mask []byte
+}
+
+func (g Grid) Nx() int {
+	return len(g.Longitudes)
+}
+
+func (g Grid) Ny() int {
+	return len(g.Latitudes)
+}
+
+// ValueBytes returns the values as little endian float32
+func (g Grid) ValueBytes() []byte {
+	data := make([]byte, 4*len(g.Values))
+	for i, value := range g.Values {
+		binary.LittleEndian.PutUint32(data[4*i:], math.Float32bits(value))
+	}
+	return data
+}
+
+// normalizeLongitude maps a longitude to [-180, 180)
+func normalizeLongitude(longitude float64) float64 {
+	return math.Mod(math.Mod(longitude+180, 360)+360, 360) - 180
+}
+
+// GetGrid extracts the field of the parameter within the bounding box at the step that contains t.
+// Only every stride-th row and column is returned. If the model has no file for the day, its parent
+// models are used.
+func GetGrid(ctx context.Context, model common.BaseModel, parameter common.ParameterOptions, t time.Time, bbox BoundingBox, stride int) (Grid, error) {
+	if stride < 1 {
+		stride = 1
+	}
+
+	ndFile, fetchedModel, err := GetNDFile(ctx, model, parameter, common.CalculateDaysSinceEpoch(t))
+	if err != nil {
+		return Grid{}, fmt.Errorf("no data for %s", parameter.DisplayName)
+	}
+
+	// Rows from south to north
+	var rows []int
+	for i, latitude := range ndFile.DistinctLatitudes {
+		if latitude >= bbox.South && latitude <= bbox.North {
+			rows = append(rows, i)
+		}
+	}
+	if len(ndFile.DistinctLatitudes) > 1 && ndFile.DistinctLatitudes[0] > ndFile.DistinctLatitudes[1] {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+
+	// Columns from west to east, starting at the western edge of the box
+	var columns []int
+	var columnLongitudes []float64
+	for j, longitude := range ndFile.DistinctLongitudes {
+		if bbox.containsLongitude(normalizeLongitude(longitude)) {
+			columns = append(columns, j)
+			columnLongitudes = append(columnLongitudes, normalizeLongitude(longitude))
+		}
+	}
+	sortColumns(columns, columnLongitudes, bbox.West)
+
+	rows = everyNth(rows, stride)
+	columns = everyNth(columns, stride)
+
+	if len(rows) == 0 || len(columns) == 0 {
+		return Grid{}, errors.New("the bounding box contains no grid cells")
+	}
+
+	if len(rows)*len(columns) > MaxGridCells {
+		return Grid{}, fmt.Errorf("the grid has more than %d cells, increase the stride", MaxGridCells)
+	}
+
+	steps := (24 * 60) / int(ndFile.TimeIntervalInMinutes)
+	dayStart := t.UTC().Truncate(24 * time.Hour)
+	step := int(t.Sub(dayStart) / (time.Duration(ndFile.TimeIntervalInMinutes) * time.Minute))
+	if step < 0 || step >= steps {
+		return Grid{}, errors.New("invalid time")
+	}
+
+	grid := Grid{
+		Model:      fetchedModel.GetModelName(),
+		Parameter:  parameter.DisplayName,
+		Unit:       parameter.Unit,
+		Time:       dayStart.Add(time.Duration(step) * time.Duration(ndFile.TimeIntervalInMinutes) * time.Minute).UnixMilli(),
+		Latitudes:  make([]float64, len(rows)),
+		Longitudes: make([]float64, len(columns)),
+		Dx:         ndFile.Dx * float64(stride),
+		Dy:         ndFile.Dy * float64(stride),
+		Values:     make([]float32, len(rows)*len(columns)),
+		Mask:       make([]byte, (len(rows)*len(columns)+7)/8),
+	}
+
+	for j, column := range columns {
+		grid.Longitudes[j] = normalizeLongitude(ndFile.DistinctLongitudes[column])
+	}
+
+	// Every row is read at once, from the first to the last requested column
+	minColumn, maxColumn := columns[0], columns[0]
+	for _, column := range columns {
+		minColumn = min(minColumn, column)
+		maxColumn = max(maxColumn, column)
+	}
+
+	cellSize := int64(2 * steps)
+	buffer := make([]byte, int64(maxColumn-minColumn+1)*cellSize)
+
+	for i, row := range rows {
+		grid.Latitudes[i] = ndFile.DistinctLatitudes[row]
+
+		offset := ndFile.HeaderLength + int64(row*int(ndFile.Nx)+minColumn)*cellSize
+		if _, err := ndFile.File.ReadAt(buffer, offset); err != nil {
+			return Grid{}, err
+		}
+
+		for j, column := range columns {
+			index := i*len(columns) + j
+			stored := int16(binary.LittleEndian.Uint16(buffer[int64(column-minColumn)*cellSize+int64(2*step):]))
+
+			if stored == 32767 {
+				grid.Values[index] = float32(math.NaN())
+				continue
+			}
+
+			grid.Values[index] = float32(parameter.Decode(stored))
+			grid.Mask[index/8] |= 1 << (index % 8)
+		}
+	}
+
+	return grid, nil
+}
+
+// sortColumns orders the columns by their longitude, starting at west
+func sortColumns(columns []int, longitudes []float64, west float64) {
+	distance := func(longitude float64) float64 {
+		return math.Mod(longitude-west+360, 360)
+	}
+
+	// Insertion sort, the columns are already sorted except for a possible wrap around
+	for i := 1; i < len(columns); i++ {
+		for j := i; j > 0 && distance(longitudes[j]) < distance(longitudes[j-1]); j-- {
+			columns[j], columns[j-1] = columns[j-1], columns[j]
+			longitudes[j], longitudes[j-1] = longitudes[j-1], longitudes[j]
+		}
+	}
+}
+
+func everyNth(indices []int, n int) []int {
+	if n <= 1 {
+		return indices
+	}
+
+	result := make([]int, 0, (len(indices)+n-1)/n)
+	for i := 0; i < len(indices); i += n {
+		result = append(result, indices[i])
+	}
+	return result
+}
+
+// GetBestModelForArea returns the model with the highest priority whose coverage contains all
+// corners of the box and that has data at the center of the box, otherwise the default model
+func GetBestModelForArea(bbox BoundingBox, t time.Time) (common.BaseModel, string) {
+	latitude, longitude := bbox.Center()
+
+	for _, modelName := range modelsByPriority() {
+		modelOptions := AvailableModels[modelName]
+
+		if modelOptions.Coverage.Contains(bbox.South, bbox.West) &&
+			modelOptions.Coverage.Contains(bbox.South, bbox.East) &&
+			modelOptions.Coverage.Contains(bbox.North, bbox.West) &&
+			modelOptions.Coverage.Contains(bbox.North, bbox.East) &&
+			HasData(modelOptions.Model, latitude, longitude, t) {
+			return modelOptions.Model, modelName
+		}
+	}
+
+	return AvailableModels[defaultModel].Model, defaultModel
+}
@@ -0,0 +1,77 @@
+package base
+
+import (
+	"fmt"
+	"hash/fnv"
+	"hstin/zephyr/common"
+	"sync"
+	"time"
+)
+
+// The run info is written by the downloader, which may run in another process, so it is
+// read from disk again after this interval
+const runInfoRefreshInterval = 10 * time.Second
+
+type cachedRunInfo struct {
+	info    common.RunInfo
+	ok      bool
+	checked time.Time
+}
+
+var runInfoCache = make(map[string]cachedRunInfo)
+var runInfoLock sync.Mutex
+
+// LatestRun returns the last run that was ingested for the model itself, false if none is known
+func LatestRun(model common.BaseModel) (common.RunInfo, bool) {
+	rootPath := model.GetRootPath()
+
+	runInfoLock.Lock()
+	defer runInfoLock.Unlock()
+
+	if cached, ok := runInfoCache[rootPath]; ok && time.Since(cached.checked) < runInfoRefreshInterval {
+		return cached.info, cached.ok
+	}
+
+	info, err := common.ReadRunInfo(rootPath)
+	runInfoCache[rootPath] = cachedRunInfo{info: info, ok: err == nil, checked: time.Now()}
+
+	return info, err == nil
+}
+
+// DataVersion describes the runs of a model and its parent models, which together supply the data of a forecast
+type DataVersion struct {
+	// Changes whenever one of the models ingests a run
+	ID string
+	// Latest ingestion of the models, zero if no run is known
+	LastModified time.Time
+	// Earliest time one of the models is expected to have a new run, zero if unknown
+	NextRun time.Time
+}
+
+func GetDataVersion(model common.BaseModel) DataVersion {
+	var version DataVersion
+
+	hash := fnv.New64a()
+
+	for _, m := range GetModelChain(model) {
+		info, ok := LatestRun(m)
+		if !ok {
+			fmt.Fprintf(hash, "%s:-;", m.GetModelName())
+			continue
+		}
+
+		fmt.Fprintf(hash, "%s:%d:%d;", m.GetModelName(), info.Run.Unix(), info.Ingested.UnixNano())
+
+		if info.Ingested.After(version.LastModified) {
+			version.LastModified = info.Ingested
+		}
+
+		if version.NextRun.IsZero() || info.NextRun.Before(version.NextRun) {
+			version.NextRun = info.NextRun
+		}
+	}
+
+	version.ID = fmt.Sprintf("%016x", hash.Sum64())
+
+	return version
+}
@@ -0,0 +1,133 @@
+package base
+
+import (
+	"context"
+	"fmt"
+	"hstin/zephyr/common"
+	"hstin/zephyr/tracing"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hstin-de/ndfile"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Days without an ND file are looked up again after this time, so a model without data is not
+// searched on every request while new data is still found soon after it is ingested
+const probeMissTTL = time.Minute
+
+type probeEntry struct {
+	// Empty if the model had no file for the day
+	file    string
+	checked time.Time
+}
+
+// probeCache maps "<rootPath>/<daysSinceEpoch>" to an ND file of that day which is used to check
+// whether a model has data for a given cell
+var probeCache map[string]probeEntry = make(map[string]probeEntry)
+var probeLock sync.Mutex
+
+// modelsByPriority returns the names of all available models, highest priority first.
+// Models with the same priority are ordered by their resolution.
+func modelsByPriority() []string {
+	modelNames := make([]string, 0, len(AvailableModels))
+	for name := range AvailableModels {
+		modelNames = append(modelNames, name)
+	}
+
+	sort.Slice(modelNames, func(i, j int) bool {
+		a, b := AvailableModels[modelNames[i]], AvailableModels[modelNames[j]]
+		if a.Priority != b.Priority {
+			return a.Priority > b.Priority
+		}
+		if a.Resolution != b.Resolution {
+			return a.Resolution < b.Resolution
+		}
+		return modelNames[i] < modelNames[j]
+	})
+
+	return modelNames
+}
+
+// HasData checks the ND files of the model itself (not its parents) and reports whether
+// the cell containing the coordinates holds a value for the given time.
+// Cells outside the native domain of a model are stored as missing values, so this acts as
+// a coverage mask derived from the data.
+func HasData(model common.BaseModel, latitude, longitude float64, t time.Time) bool {
+	daysSinceEpoch := common.CalculateDaysSinceEpoch(t)
+
+	probeFile := findProbeFile(model.GetRootPath(), daysSinceEpoch)
+	if probeFile == "" {
+		return false
+	}
+
+	ndFile, err := openNDFile(context.Background(), probeFile)
+	if err != nil {
+		return false
+	}
+
+	latIndex, lngIndex := ndFile.GetIndex(latitude, longitude)
+
+	values, err := ndFile.GetData(latIndex, lngIndex)
+	if err != nil {
+		return false
+	}
+
+	step := int(t.UTC().Sub(t.UTC().Truncate(24*time.Hour)).Minutes()) / int(ndFile.TimeIntervalInMinutes)
+	if step < 0 || step >= len(values) {
+		return false
+	}
+
+	return values[step] != 32767
+}
+
+func findProbeFile(rootPath string, daysSinceEpoch int) string {
+	key := fmt.Sprintf("%s/%d", rootPath, daysSinceEpoch)
+
+	probeLock.Lock()
+	defer probeLock.Unlock()
+
+	if entry, ok := probeCache[key]; ok && (entry.file != "" || time.Since(entry.checked) < probeMissTTL) {
+		return entry.file
+	}
+
+	entry := probeEntry{checked: time.Now()}
+
+	matches, err := filepath.Glob(filepath.Join(rootPath, fmt.Sprintf("*_%d.nd", daysSinceEpoch)))
+	if err == nil && len(matches) > 0 {
+		sort.Strings(matches)
+		entry.file = matches[0]
+	}
+
+	probeCache[key] = entry
+
+	return entry.file
+}
+
+func openNDFile(ctx context.Context, path string) (ndfile.NDFile, error) {
+	cacheLock.RLock()
+	cachedFile, ok := cache[path]
+	cacheLock.RUnlock()
+
+	if ok {
+		return cachedFile, nil
+	}
+
+	_, span := tracing.Start(ctx, "ndfile.PreFetch", attribute.String("path", path))
+	defer span.End()
+
+	ndFile, err := ndfile.PreFetch(path)
+	if err != nil {
+		// Missing files are expected, the data is read from the parent model instead
+		span.SetAttributes(attribute.Bool("found", false))
+		return ndfile.NDFile{}, err
+	}
+
+	cacheLock.Lock()
+	cache[path] = ndFile
+	cacheLock.Unlock()
+
+	return ndFile, nil
+}
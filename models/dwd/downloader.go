@@ -2,17 +2,25 @@ package dwd
 
 import (
 	"compress/bzip2"
+	"context"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"hstin/zephyr/common"
 	. "hstin/zephyr/helper"
+	"hstin/zephyr/source"
+	"hstin/zephyr/tracing"
+
+	"github.com/hstin-de/ndfile"
+	"go.opentelemetry.io/otel/attribute"
 
 	_ "golang.org/x/net/http2"
 )
@@ -26,6 +34,30 @@ type DWDModel struct {
 	urlFormat                     string
 	maxStep                       map[int]int
 	breakPoint                    int
+	// Inserted in front of the variable name of single-level files
+	singleLevelSegment string
+	// Set for URL formats of older configurations without the level placeholders, which only
+	// support single-level files
+	singleLevelFormat bool
+	// Model level read for each height above ground in meters, the DWD provides no fields at fixed heights
+	heightLevels map[int]int
+	// Files are read from the open data server of the DWD unless a mirror or local source is configured
+	source source.Source
+}
+
+// Open data server of the DWD, the path formats of the models are relative to it
+var dwdOpenData = source.NewHTTP("https://opendata.dwd.de", nil)
+
+// Number of verbs of the URL formats with and without the level placeholders
+const (
+	urlFormatVerbs            = 12
+	singleLevelURLFormatVerbs = 10
+)
+
+var dwdLevelTypes = map[common.LevelType]string{
+	common.SINGLE_LEVEL:   "single-level",
+	common.PRESSURE_LEVEL: "pressure-level",
+	common.MODEL_LEVEL:    "model-level",
 }
 
 var dwdModels = map[string]DWDModel{
@@ -35,7 +67,7 @@ var dwdModels = map[string]DWDModel{
 		intervalHours:                 6,
 		grid:                          "icosahedral",
 		area:                          "global",
-		urlFormat:                     "https://opendata.dwd.de/weather/nwp/%sL/grib/%s/%sL/%sL_%s_%s_single-level_%s%s_%s_%sU.grib2.bz2",
+		urlFormat:                     "weather/nwp/%sL/grib/%s/%sL/%sL_%s_%s_%s_%s%s_%s_%s%sU.grib2.bz2",
 		maxStep: map[int]int{
 			0:  180,
 			6:  120,
@@ -43,6 +75,9 @@ var dwdModels = map[string]DWDModel{
 			18: 120,
 		},
 		breakPoint: 78,
+		// Nearest of the 120 levels over flat terrain, about 112 m and 165 m above ground
+		heightLevels: map[int]int{120: 116, 180: 115},
+		source:       dwdOpenData,
 	},
 	"icon-d2": {
 		model:                         "icon-d2",
@@ -50,14 +85,18 @@ var dwdModels = map[string]DWDModel{
 		intervalHours:                 12,
 		grid:                          "icosahedral",
 		area:                          "germany",
-		urlFormat:                     "https://opendata.dwd.de/weather/nwp/%sL/grib/%s/%sL/%sL_%s_%s_single-level_%s%s_%s_2d_%sL.grib2.bz2",
+		urlFormat:                     "weather/nwp/%sL/grib/%s/%sL/%sL_%s_%s_%s_%s%s_%s_%s%sL.grib2.bz2",
 		maxStep: map[int]int{
 			0:  180,
 			6:  120,
 			12: 180,
 			18: 120,
 		},
-		breakPoint: 24,
+		breakPoint:         24,
+		singleLevelSegment: "2d_",
+		// Nearest of the 65 levels over flat terrain, about 112 m and 165 m above ground
+		heightLevels: map[int]int{120: 62, 180: 61},
+		source:       dwdOpenData,
 	},
 	"icon-eu": {
 		model:                         "icon-eu",
@@ -65,7 +104,7 @@ var dwdModels = map[string]DWDModel{
 		intervalHours:                 3,
 		grid:                          "regular-lat-lon",
 		area:                          "europe",
-		urlFormat:                     "https://opendata.dwd.de/weather/nwp/%sL/grib/%s/%sL/%sL_%s_%s_single-level_%s%s_%s_%sU.grib2.bz2",
+		urlFormat:                     "weather/nwp/%sL/grib/%s/%sL/%sL_%s_%s_%s_%s%s_%s_%s%sU.grib2.bz2",
 		maxStep: map[int]int{
 			0:  120,
 			3:  30,
@@ -77,22 +116,133 @@ var dwdModels = map[string]DWDModel{
 			21: 30,
 		},
 		breakPoint: 78,
+		// Nearest of the 74 levels over flat terrain, about 112 m and 165 m above ground
+		heightLevels: map[int]int{120: 71, 180: 70},
+		source:       dwdOpenData,
 	},
 }
 
-var ICONParameterLookup map[string]string = map[string]string{
-	"temperature":          "T_2M",
-	"clouds":               "CLCT",
-	"condition":            "WW",
-	"cape":                 "CAPE_CON",
-	"wind_u":               "U_10M",
-	"wind_v":               "V_10M",
-	"relative_humidity":    "RELHUM_2M",
-	"surface_pressure":     "PMSL",
-	"dewpoint":             "TD_2M",
-	"snow_depth":           "H_SNOW",
-	"surface_pressure_msl": "PS",
-	"precipitation":        "TOT_PREC",
+// DWDModelOptions overrides the download settings of a model. Unset values are
+// taken from the built-in model with the same remote model name.
+type DWDModelOptions struct {
+	Model                         string
+	URLFormat                     string
+	OpenDataDeliveryOffsetMinutes int
+	IntervalHours                 int
+	Grid                          string
+	Area                          string
+	MaxStep                       map[int]int
+	BreakPoint                    int
+	// Model level of each height above ground in meters, replaces the built-in levels
+	HeightLevels map[int]int
+	// Replaces the source of the model, the open data server or the server of an absolute URLFormat if nil
+	Source source.Source
+}
+
+// RegisterModel adds or replaces the download settings of a model
+func RegisterModel(name string, opt DWDModelOptions) error {
+	if opt.Model == "" {
+		opt.Model = name
+	}
+
+	details, ok := dwdModels[opt.Model]
+	if !ok && opt.URLFormat == "" {
+		return fmt.Errorf("unknown DWD model '%s', url_format is required", opt.Model)
+	}
+
+	details.model = opt.Model
+
+	if opt.URLFormat != "" {
+		// Absolute formats of older configurations are split into the server and the path
+		baseURL, pathFormat, absolute := source.SplitURL(opt.URLFormat)
+		if absolute {
+			details.source = source.NewHTTP(baseURL, nil)
+		}
+
+		singleLevel, err := CheckURLFormat(pathFormat)
+		if err != nil {
+			return fmt.Errorf("DWD model '%s': %w", name, err)
+		}
+
+		details.urlFormat = pathFormat
+		details.singleLevelFormat = singleLevel
+	}
+	if len(opt.HeightLevels) != 0 {
+		details.heightLevels = opt.HeightLevels
+	}
+	if opt.Source != nil {
+		details.source = opt.Source
+	}
+	if details.source == nil {
+		details.source = dwdOpenData
+	}
+	if opt.OpenDataDeliveryOffsetMinutes != 0 {
+		details.openDataDeliveryOffsetMinutes = opt.OpenDataDeliveryOffsetMinutes
+	}
+	if opt.IntervalHours != 0 {
+		details.intervalHours = opt.IntervalHours
+	}
+	if opt.Grid != "" {
+		details.grid = opt.Grid
+	}
+	if opt.Area != "" {
+		details.area = opt.Area
+	}
+	if len(opt.MaxStep) != 0 {
+		details.maxStep = opt.MaxStep
+	}
+	if opt.BreakPoint != 0 {
+		details.breakPoint = opt.BreakPoint
+	}
+
+	if details.intervalHours == 0 || len(details.maxStep) == 0 || details.area == "" || details.grid == "" {
+		return fmt.Errorf("incomplete download settings for DWD model '%s'", name)
+	}
+
+	dwdModels[name] = details
+
+	return nil
+}
+
+// CheckURLFormat checks the number of verbs of a URL format. Formats of older configurations
+// without the level type and level placeholders are supported for single-level parameters.
+func CheckURLFormat(format string) (singleLevel bool, err error) {
+	verbs := 0
+	for i, part := range strings.Split(format, "%") {
+		if i > 0 && strings.HasPrefix(part, "s") {
+			verbs++
+		}
+	}
+
+	switch verbs {
+	case urlFormatVerbs:
+		return false, nil
+	case singleLevelURLFormatVerbs:
+		return true, nil
+	}
+
+	return false, fmt.Errorf("url_format has %d verbs, expected %d (or %d for single-level parameters only)", verbs, urlFormatVerbs, singleLevelURLFormatVerbs)
+}
+
+// fileLevel returns the level of the files that are downloaded for a parameter on level, heights
+// above ground are read from a model level. ok is false if the level is not available.
+func (m DWDModel) fileLevel(level common.Level) (fileLevel common.Level, ok bool) {
+	if m.singleLevelFormat && level.Type != common.SINGLE_LEVEL {
+		return level, false
+	}
+
+	if level.Type == common.HEIGHT_LEVEL {
+		modelLevel, ok := m.heightLevels[level.Value]
+		return common.Level{Type: common.MODEL_LEVEL, Value: modelLevel}, ok
+	}
+
+	return level, true
+}
+
+// HasModel reports whether download settings exist for the remote model name
+func HasModel(name string) bool {
+	_, ok := dwdModels[name]
+	return ok
 }
 
 type DWDOpenDataDownloader struct {
@@ -101,10 +251,11 @@ type DWDOpenDataDownloader struct {
 	tmpFolder       string
 	descriptionFile string
 	weightsFile     string
+	cdoPath         string
 	maxStep         int
 	regrid          bool
 	modelDetails    DWDModel
-	httpClient      *http.Client
+	scheduler       *common.Scheduler
 	Fast            bool
 }
 
@@ -112,10 +263,17 @@ type DWDOpenDataDownloaderOptions struct {
 	ModelName    string
 	Params       []string
 	OutputFolder string
+	TmpFolder    string
+	WeightsPath  string
+	CdoPath      string
 	MaxStep      int
 	Regrid       bool
 	ModelDetails DWDModel
 	Fast         bool
+	// Downloaded steps are written to the ND files of the manager
+	NDFileManager *ndfile.NDFileManager
+	Budget        *common.MemoryBudget
+	Scheduler     *common.Scheduler
 }
 
 func formatString(format string, args ...interface{}) string {
@@ -123,7 +281,8 @@ func formatString(format string, args ...interface{}) string {
 	var result string
 
 	for i, part := range formatParts {
-		if i == 0 && !strings.HasPrefix(part, "sU") && !strings.HasPrefix(part, "sL") && !strings.HasPrefix(part, "s") {
+		// Everything before the first verb is copied as is
+		if i == 0 {
 			result += part
 			continue
 		}
@@ -158,13 +317,8 @@ func formatString(format string, args ...interface{}) string {
 
 func NewDWDOpenDataDownloader(options DWDOpenDataDownloaderOptions) *DWDOpenDataDownloader {
 
-	tmpFolder := "/tmp/gribdl/dwd"
-
-	currentPath, err := os.Getwd()
-	if err != nil {
-		Log.Error().Err(err).Msg("Error getting current path")
-	}
-	weightsDir := currentPath + "/weights"
+	tmpFolder := options.TmpFolder
+	weightsDir := options.WeightsPath
 
 	if _, err := os.Stat(tmpFolder); os.IsNotExist(err) {
 		os.MkdirAll(tmpFolder, 0755)
@@ -174,12 +328,13 @@ func NewDWDOpenDataDownloader(options DWDOpenDataDownloaderOptions) *DWDOpenData
 		modelName:       options.ModelName,
 		params:          options.Params,
 		tmpFolder:       tmpFolder,
-		descriptionFile: weightsDir + "/" + dwdModels[options.ModelName].model + "_description.txt",
-		weightsFile:     weightsDir + "/" + dwdModels[options.ModelName].model + "_weights.nc",
+		descriptionFile: filepath.Join(weightsDir, dwdModels[options.ModelName].model+"_description.txt"),
+		weightsFile:     filepath.Join(weightsDir, dwdModels[options.ModelName].model+"_weights.nc"),
+		cdoPath:         options.CdoPath,
 		maxStep:         options.MaxStep,
 		regrid:          options.Regrid,
 		modelDetails:    options.ModelDetails,
-		httpClient:      &http.Client{Timeout: 5 * time.Minute},
+		scheduler:       options.Scheduler,
 		Fast:            options.Fast,
 	}
 }
@@ -189,31 +344,46 @@ func (wdp *DWDOpenDataDownloader) getMostRecentModelTimestamp() time.Time {
 	return time.Now().UTC().Add(offset).Truncate(time.Duration(wdp.modelDetails.intervalHours) * time.Hour)
 }
 
-func (wdp *DWDOpenDataDownloader) getGribFileUrl(param string, date time.Time, step int) string {
+// getGribFilePath returns the path of the file relative to the root of the source
+func (wdp *DWDOpenDataDownloader) getGribFilePath(param string, level common.Level, date time.Time, step int) string {
 	hour := fmt.Sprintf("%02d", date.UTC().Hour())
 	year, month, day := date.UTC().Date()
 	model := wdp.modelDetails.model
 
+	if wdp.modelDetails.singleLevelFormat {
+		return formatString(wdp.modelDetails.urlFormat,
+			model, hour,
+			param, model,
+			wdp.modelDetails.area, wdp.modelDetails.grid,
+			fmt.Sprintf("%04d%02d%02d", year, month, day), hour, fmt.Sprintf("%03d", step),
+			param)
+	}
+
+	levelSegment := wdp.modelDetails.singleLevelSegment
+	if level.Type != common.SINGLE_LEVEL {
+		levelSegment = fmt.Sprintf("%d_", level.Value)
+	}
+
 	return formatString(wdp.modelDetails.urlFormat,
 		model, hour,
 		param, model,
-		wdp.modelDetails.area, wdp.modelDetails.grid,
+		wdp.modelDetails.area, wdp.modelDetails.grid, dwdLevelTypes[level.Type],
 		fmt.Sprintf("%04d%02d%02d", year, month, day), hour, fmt.Sprintf("%03d", step),
-		param)
+		levelSegment, param)
 }
 
 func (wdp *DWDOpenDataDownloader) regridFile(filePath string) string {
 
 	regridFile := strings.Replace(filePath, ".grib2", "_regrid.grib2", -1)
 
-	cmd := exec.Command("cdo", "-f", "grb2", "remap,"+wdp.descriptionFile+","+wdp.weightsFile, filePath, regridFile)
+	cmd := exec.Command(wdp.cdoPath, "-f", "grb2", "remap,"+wdp.descriptionFile+","+wdp.weightsFile, filePath, regridFile)
 	if err := cmd.Run(); err != nil {
-		Log.Error().Err(err).Msg("Error regridding file")
+		DWDLog.Error().Err(err).Msg("Error regridding file")
 		return filePath
 	}
 
 	if err := os.Remove(filePath); err != nil {
-		Log.Error().Err(err).Msg("Error removing original file")
+		DWDLog.Error().Err(err).Msg("Error removing original file")
 		return filePath
 	}
 
@@ -221,54 +391,37 @@ func (wdp *DWDOpenDataDownloader) regridFile(filePath string) string {
 
 }
 
-func (wdp *DWDOpenDataDownloader) downloadAndProcessFile(url string, retries int) ([]byte, error) {
-	resp, err := wdp.httpClient.Get(url)
-	if err != nil {
-		if retries > 0 {
-			Log.Info().Msgf("[DL] Retrying... Error: %s", err)
-			return wdp.downloadAndProcessFile(url, retries-1)
-		}
-		return nil, fmt.Errorf("[DL] getting url: %w", err)
-	}
-	defer resp.Body.Close()
+func (wdp *DWDOpenDataDownloader) downloadAndProcessFile(ctx context.Context, filePath string) ([]byte, error) {
+	tmpFile := filepath.Join(wdp.tmpFolder, strings.TrimSuffix(path.Base(filePath), ".bz2"))
 
-	if resp.StatusCode != http.StatusOK {
-		if retries > 0 {
-			Log.Info().Msgf("[DL] Retrying.... Status code: %d", resp.StatusCode)
-			return wdp.downloadAndProcessFile(url, retries-1)
+	err := wdp.scheduler.Fetch(ctx, wdp.modelDetails.source, filePath, nil, func(body io.Reader) error {
+		outputFile, err := os.Create(tmpFile)
+		if err != nil {
+			return fmt.Errorf("[DL] creating file: %w", err)
 		}
-		return nil, fmt.Errorf("[DL] non-200 status code: %d", resp.StatusCode)
-	}
-
-	bz2Reader := bzip2.NewReader(resp.Body)
-	fileName := filepath.Base(url)
+		defer outputFile.Close()
 
-	filePath := filepath.Join(wdp.tmpFolder, strings.TrimSuffix(fileName, ".bz2"))
+		if _, err = io.Copy(outputFile, bzip2.NewReader(body)); err != nil {
+			return fmt.Errorf("[DL] copying file: %w", err)
+		}
 
-	outputFile, err := os.Create(filePath)
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("[DL] creating file: %w", err)
-	}
-	defer outputFile.Close()
-
-	if _, err = io.Copy(outputFile, bz2Reader); err != nil {
-		if retries > 0 {
-			Log.Info().Msgf("[DL] Retrying.... Error: %s", err)
-			return wdp.downloadAndProcessFile(url, retries-1)
-		}
-		return nil, fmt.Errorf("[DL] copying file: %w", err)
+		os.Remove(tmpFile)
+		return nil, fmt.Errorf("[DL] getting %s: %w", filePath, err)
 	}
 
 	if wdp.regrid && wdp.modelDetails.grid != "regular-lat-lon" {
-		filePath = wdp.regridFile(filePath)
+		tmpFile = wdp.regridFile(tmpFile)
 	}
 
-	gribFile, err := os.ReadFile(filePath)
+	gribFile, err := os.ReadFile(tmpFile)
 	if err != nil {
 		return nil, fmt.Errorf("[MOVE] opening source file: %w", err)
 	}
 
-	err = os.Remove(filePath)
+	err = os.Remove(tmpFile)
 	if err != nil {
 		return nil, fmt.Errorf("[MOVE] removing original file: %w", err)
 	}
@@ -276,9 +429,18 @@ func (wdp *DWDOpenDataDownloader) downloadAndProcessFile(url string, retries int
 	return gribFile, nil
 }
 
-func (wdp *DWDOpenDataDownloader) DownloadStep(param string, step int, timestamp time.Time) ([]byte, error) {
-	url := wdp.getGribFileUrl(param, timestamp, step)
-	gribFile, err := wdp.downloadAndProcessFile(url, 5)
+func (wdp *DWDOpenDataDownloader) DownloadStep(ctx context.Context, param string, level common.Level, step int, timestamp time.Time) ([]byte, error) {
+	filePath := wdp.getGribFilePath(param, level, timestamp, step)
+
+	ctx, span := tracing.Start(ctx, "download step",
+		attribute.String("model", wdp.modelName),
+		attribute.String("parameter", param),
+		attribute.Int("step", step),
+		attribute.String("path", filePath),
+	)
+
+	gribFile, err := wdp.downloadAndProcessFile(ctx, filePath)
+	tracing.End(span, err)
 	if err != nil {
 		return nil, err
 	}
@@ -286,14 +448,24 @@ func (wdp *DWDOpenDataDownloader) DownloadStep(param string, step int, timestamp
 	return gribFile, nil
 }
 
-func StartDWDDownloader(options DWDOpenDataDownloaderOptions) (map[string]map[int][]byte, int) {
+// nextRun returns the time the run after run is expected to be available
+func (m DWDModel) nextRun(run time.Time) time.Time {
+	return run.Add(time.Duration(m.intervalHours)*time.Hour + time.Duration(m.openDataDeliveryOffsetMinutes)*time.Minute)
+}
+
+// StartDWDDownloader downloads the steps of the most recent run and writes every step to the
+// ND files as soon as it is downloaded. The reference time of the run is returned, it is zero if
+// no file was downloaded. complete is true if every expected step of every parameter was written.
+// Every step is spooled to the temp folder, steps of the run that were downloaded by an
+// interrupted download are reused.
+func StartDWDDownloader(ctx context.Context, options DWDOpenDataDownloaderOptions) (run time.Time, complete bool) {
 	modelDetails, exists := dwdModels[options.ModelName]
 	if !exists {
-		Log.Error().Msg("Model not found")
+		DWDLog.Error().Msg("Model not found")
 		for key := range dwdModels {
-			Log.Info().Msg(key)
+			DWDLog.Info().Msg(key)
 		}
-		return nil, 0
+		return time.Time{}, false
 	}
 
 	options.ModelDetails = modelDetails
@@ -306,72 +478,111 @@ func StartDWDDownloader(options DWDOpenDataDownloaderOptions) (map[string]map[in
 		wdp.maxStep = options.ModelDetails.maxStep[timestamp.Hour()]
 	}
 
-	gribFiles := make(map[string]map[int][]byte)
-	var mu sync.Mutex
+	spool, err := common.OpenSpool(wdp.tmpFolder, wdp.modelName, timestamp)
+	if err != nil {
+		DWDLog.Warn().Err(err).Msgf("[%s] Could not open spool, downloads can not be resumed", wdp.modelName)
+	}
+	defer spool.Close()
+
+	var steps []int
+	for step := 0; step < min(wdp.maxStep, wdp.modelDetails.breakPoint); step++ {
+		steps = append(steps, step)
+	}
+	for step := wdp.modelDetails.breakPoint; step <= wdp.maxStep; step += 3 {
+		steps = append(steps, step)
+	}
+
+	var downloaded atomic.Int64
+	var incomplete atomic.Bool
 	var wg sync.WaitGroup
 
-	downloadStep := func(p string, step int) ([]byte, error) {
-		gribFile, err := wdp.DownloadStep(p, step, timestamp)
+	downloadStep := func(p string, source string, level common.Level, step int) ([]byte, error) {
+		if gribFile, ok := spool.Get(p, step); ok {
+			return gribFile, nil
+		}
+
+		if err := options.Scheduler.Acquire(ctx); err != nil {
+			return nil, err
+		}
+		gribFile, err := wdp.DownloadStep(ctx, source, level, step, timestamp)
+		options.Scheduler.Release()
 		if err != nil {
 			return nil, err
 		}
+
+		if err := spool.Put(p, step, gribFile); err != nil {
+			DWDLog.Warn().Err(err).Msgf("[%s] Could not spool step %d of %s", wdp.modelName, step, p)
+		}
+
 		return gribFile, nil
 	}
 
-	processParam := func(p string) {
+	processParam := func(p string, source string, level common.Level) {
 		defer wg.Done()
-		firstLoop := wdp.maxStep
 
-		if wdp.maxStep >= wdp.modelDetails.breakPoint {
-			firstLoop = wdp.modelDetails.breakPoint
+		stream, err := common.NewParameterStream(ctx, p, timestamp, steps, options.NDFileManager, options.Budget)
+		if err != nil {
+			DWDLog.Error().Err(err).Msgf("[%s] Could not process %s", wdp.modelName, p)
+			incomplete.Store(true)
+			return
 		}
+		defer func() {
+			stream.Close()
+			if !stream.Complete() {
+				incomplete.Store(true)
+			}
+		}()
 
-		for step := 0; step < firstLoop; step++ {
-			gribFile, err := downloadStep(ICONParameterLookup[p], step)
-			if err != nil {
+		for _, step := range steps {
+			if err := options.Budget.Wait(ctx); err != nil {
 				return
 			}
-			mu.Lock()
-			gribFiles[p][step] = gribFile
-			mu.Unlock()
-			Log.Info().Msgf("[%s] Downloaded %s %d/%d", wdp.modelName, p, step+1, wdp.maxStep)
-		}
 
-		for step := wdp.modelDetails.breakPoint; step <= wdp.maxStep; step += 3 {
-			gribFile, err := downloadStep(ICONParameterLookup[p], step)
+			// The following steps are not downloaded, the stream is incomplete and the spool is kept
+			gribFile, err := downloadStep(p, source, level, step)
 			if err != nil {
+				DWDLog.Error().Err(err).Msgf("[%s] Could not download step %d of %s", wdp.modelName, step, p)
 				return
 			}
-			mu.Lock()
-			gribFiles[p][step] = gribFile
-			mu.Unlock()
-			Log.Info().Msgf("[%s] Downloaded %s %d/%d", wdp.modelName, p, step+1, wdp.maxStep)
+
+			stream.Add(step, gribFile)
+			downloaded.Add(1)
+			DWDLog.Info().Msgf("[%s] Downloaded %s %d/%d", wdp.modelName, p, step+1, wdp.maxStep)
 		}
 	}
 
-	Log.Info().Msgf("Downloading %s with Fast Mode: %t", wdp.modelName, wdp.Fast)
+	DWDLog.Info().Msgf("Downloading %s with Fast Mode: %t", wdp.modelName, wdp.Fast)
 
 	for _, p := range options.Params {
 
-		if _, ok := ICONParameterLookup[p]; !ok {
-			Log.Warn().Msgf("Parameter %s not found. skipping...", p)
+		parameter := common.Parameters[p]
+
+		source, ok := parameter.Source("dwd", wdp.modelName)
+		if !ok {
+			DWDLog.Warn().Msgf("Parameter %s not found. skipping...", p)
 			continue
 		}
 
-		mu.Lock()
-		gribFiles[p] = make(map[int][]byte)
-		mu.Unlock()
+		level, ok := wdp.modelDetails.fileLevel(parameter.Level)
+		if !ok {
+			DWDLog.Warn().Msgf("[%s] Level %s of %s is not available. skipping...", wdp.modelName, parameter.Level, p)
+			continue
+		}
 
 		if wdp.Fast {
 			wg.Add(1)
-			go processParam(p)
+			go processParam(p, source, level)
 		} else {
 			wg.Add(1)
-			processParam(p)
+			processParam(p, source, level)
 		}
 	}
 
 	wg.Wait()
 
-	return gribFiles, modelDetails.breakPoint
+	if downloaded.Load() == 0 {
+		return time.Time{}, false
+	}
+
+	return timestamp, !incomplete.Load()
 }
@@ -0,0 +1,108 @@
+package dwd
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"context"
+	"fmt"
+	"hstin/zephyr/common"
+	"hstin/zephyr/source"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hstin-de/ndfile"
+)
+
+// copyFixture copies the file of testdata to path below dir
+func copyFixture(t *testing.T, fixture, dir, path string) {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("testdata", fixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	target := filepath.Join(dir, filepath.FromSlash(path))
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(target, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// The downloaded files are not valid GRIB messages, so the steps are spooled but can not be
+// decoded and the download of the run is incomplete, with and without the eccodes library
+func TestStartDWDDownloaderLocalSource(t *testing.T) {
+	const model = "fixture-icon-eu"
+	// Steps up to the break point are hourly, the download ends before MaxStep
+	const maxStep = 2
+
+	sourceDir := t.TempDir()
+	tmpDir := t.TempDir()
+
+	if err := RegisterModel(model, DWDModelOptions{Model: "icon-eu", Source: source.NewLocal(sourceDir)}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { delete(dwdModels, model) })
+
+	common.ResetParameters()
+	t.Cleanup(common.ResetParameters)
+
+	if err := common.RegisterParameter("temperature", common.ParameterOptions{Sources: map[string]string{"dwd": "T_2M"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Most recent run of icon-eu, which is delivered 4 hours after the reference time
+	want := time.Now().UTC().Add(-240 * time.Minute).Truncate(3 * time.Hour)
+
+	for step := 0; step < maxStep; step++ {
+		copyFixture(t, "t_2m.grib2.bz2", sourceDir, fmt.Sprintf(
+			"weather/nwp/icon-eu/grib/%02d/t_2m/icon-eu_europe_regular-lat-lon_single-level_%s_%03d_T_2M.grib2.bz2",
+			want.Hour(), want.Format("2006010215"), step))
+	}
+
+	run, complete := StartDWDDownloader(context.Background(), DWDOpenDataDownloaderOptions{
+		ModelName:     model,
+		Params:        []string{"temperature"},
+		TmpFolder:     tmpDir,
+		MaxStep:       maxStep,
+		NDFileManager: &ndfile.NDFileManager{RootPath: t.TempDir()},
+	})
+
+	if !run.Equal(want) {
+		t.Fatalf("got run %v, want %v", run, want)
+	}
+	if complete {
+		t.Error("got a complete run for steps that can not be decoded")
+	}
+
+	compressed, err := os.ReadFile(filepath.Join("testdata", "t_2m.grib2.bz2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixture, err := io.ReadAll(bzip2.NewReader(bytes.NewReader(compressed)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spool, err := common.OpenSpool(tmpDir, model, run)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer spool.Close()
+
+	for step := 0; step < maxStep; step++ {
+		data, ok := spool.Get("temperature", step)
+		if !ok {
+			t.Errorf("step %d was not spooled", step)
+			continue
+		}
+		if !bytes.Equal(data, fixture) {
+			t.Errorf("step %d: spooled %d bytes, want the %d decompressed bytes of the fixture", step, len(data), len(fixture))
+		}
+	}
+}
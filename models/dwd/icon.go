@@ -1,14 +1,18 @@
 package dwd
 
 import (
+	"context"
 	"hstin/zephyr/common"
 	. "hstin/zephyr/helper"
+	"hstin/zephyr/tracing"
 	"os"
 	"path"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/hstin-de/ndfile"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const (
@@ -21,12 +25,18 @@ type IconModel struct {
 	ModelName     string
 	NDFileManager *ndfile.NDFileManager
 	ParentModel   common.BaseModel
+	WeightsPath   string
+	TmpPath       string
+	CdoPath       string
 }
 
 type IconModelOptions struct {
 	RootPath    string
 	ModelName   string
 	ParentModel common.BaseModel
+	WeightsPath string
+	TmpPath     string
+	CdoPath     string
 }
 
 func NewIconModel(opt IconModelOptions) *IconModel {
@@ -34,7 +44,7 @@ func NewIconModel(opt IconModelOptions) *IconModel {
 	rootPath := path.Join(opt.RootPath, opt.ModelName)
 
 	if os.MkdirAll(rootPath, os.ModePerm) != nil {
-		Log.Fatal().Msgf("Could not create root path for model '%s'", opt.ModelName)
+		DWDLog.Fatal().Msgf("Could not create root path for model '%s'", opt.ModelName)
 	}
 
 	return &IconModel{
@@ -42,6 +52,9 @@ func NewIconModel(opt IconModelOptions) *IconModel {
 		ModelName:     opt.ModelName,
 		NDFileManager: ndfile.NewNDFileManager(rootPath, TimeIntervalInMinutes),
 		ParentModel:   opt.ParentModel,
+		WeightsPath:   opt.WeightsPath,
+		TmpPath:       path.Join(opt.TmpPath, "dwd"),
+		CdoPath:       opt.CdoPath,
 	}
 }
 
@@ -59,12 +72,13 @@ func (m *IconModel) GetParentModel() common.BaseModel {
 
 var gribFileMutex sync.Mutex
 
-func (m *IconModel) DowloadParameter(parameter []string, fast bool) error {
+func (m *IconModel) DowloadParameter(parameter []string, options common.DownloadOptions) error {
 
 	GenerateWeights(WeightOptions{
-		GridsPath:   "/tmp/gribdl/dwd/grids",
-		WeightsPath: "./weights",
-		CdoPath:     "cdo",
+		GridsPath:   path.Join(m.TmpPath, "grids"),
+		WeightsPath: m.WeightsPath,
+		CdoPath:     m.CdoPath,
+		Source:      dwdModels[m.ModelName].source,
 	})
 
 	var downloadParams []string = make([]string, len(parameter))
@@ -76,46 +90,50 @@ func (m *IconModel) DowloadParameter(parameter []string, fast bool) error {
 		}
 	}
 
-	Log.Info().Msg("Downloading parameters: " + strings.Join(downloadParams, ", "))
-
-	var wg sync.WaitGroup
-
-	if fast {
-
-		downloadedGribFiles, breakPoint := StartDWDDownloader(DWDOpenDataDownloaderOptions{
-			ModelName: m.ModelName,
-			Params:    downloadParams,
-			MaxStep:   MaxStep,
-			Regrid:    true,
-			Fast:      fast,
-		})
-
-		Log.Info().Msgf("[%s] Download complete. Processing parameters", m.ModelName)
-
-		for _, p := range downloadParams {
-			wg.Add(1)
-			Log.Info().Msgf("[%s] Processing parameter: %s", m.ModelName, p)
-			go common.ProcessParameter(p, downloadedGribFiles, breakPoint, &wg, m.NDFileManager)
-		}
+	DWDLog.Info().Msg("Downloading parameters: " + strings.Join(downloadParams, ", "))
+
+	ctx, span := tracing.Start(context.Background(), "download model",
+		attribute.String("model", m.ModelName),
+		attribute.StringSlice("parameters", downloadParams),
+	)
+	defer span.End()
+
+	// Steps are written to the ND files while the download is running
+	run, complete := StartDWDDownloader(ctx, DWDOpenDataDownloaderOptions{
+		ModelName:     m.ModelName,
+		Params:        downloadParams,
+		MaxStep:       MaxStep,
+		Regrid:        true,
+		Fast:          options.Fast,
+		TmpFolder:     m.TmpPath,
+		WeightsPath:   m.WeightsPath,
+		CdoPath:       m.CdoPath,
+		NDFileManager: m.NDFileManager,
+		Budget:        options.Budget,
+		Scheduler:     options.Scheduler,
+	})
 
-	} else {
+	if run.IsZero() {
+		return nil
+	}
 
-		for _, p := range downloadParams {
-			downloadedGribFiles, breakPoint := StartDWDDownloader(DWDOpenDataDownloaderOptions{
-				ModelName: m.ModelName,
-				Params:    []string{p},
-				MaxStep:   MaxStep,
-				Regrid:    true,
-			})
+	if !complete {
+		// The run info still points to the previous run, the next download of the run resumes from the spool
+		DWDLog.Warn().Msgf("[%s] Download of run %s is incomplete, keeping the spool", m.ModelName, run.Format(time.RFC3339))
+		return nil
+	}
 
-			wg.Add(1)
-			Log.Info().Msgf("[%s] Processing parameter: %s", m.ModelName, p)
-			common.ProcessParameter(p, downloadedGribFiles, breakPoint, &wg, m.NDFileManager)
-		}
+	DWDLog.Info().Msgf("[%s] Download complete", m.ModelName)
 
+	info := common.RunInfo{Run: run, Ingested: time.Now().UTC(), NextRun: dwdModels[m.ModelName].nextRun(run)}
+	if err := common.WriteRunInfo(m.RootPath, info); err != nil {
+		DWDLog.Warn().Err(err).Msgf("[%s] Could not write run info", m.ModelName)
 	}
 
-	wg.Wait()
+	// The ND files of the run are written, the spooled steps are not needed for a resume anymore
+	if err := common.RemoveSpool(m.TmpPath, m.ModelName, run); err != nil {
+		DWDLog.Warn().Err(err).Msgf("[%s] Could not remove spool", m.ModelName)
+	}
 
 	return nil
 
@@ -2,10 +2,11 @@ package dwd
 
 import (
 	"compress/bzip2"
+	"context"
 	"embed"
 	. "hstin/zephyr/helper"
+	"hstin/zephyr/source"
 	"io"
-	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -19,6 +20,8 @@ type WeightOptions struct {
 	GridsPath   string
 	WeightsPath string
 	CdoPath     string
+	// Source of the grid files, they are read from "weather/lib/cdo" like on the open data server
+	Source source.Source
 }
 
 type WeightsDetails struct {
@@ -68,20 +71,20 @@ func GenerateWeights(opts WeightOptions) {
 	// copy the description and sample files to the weights path
 	files, err := weights.ReadDir("icon_weights")
 	if err != nil {
-		Log.Fatal().Err(err).Msg("Error reading weights directory")
+		DWDLog.Fatal().Err(err).Msg("Error reading weights directory")
 	}
 
 	for _, file := range files {
 		if _, err := os.Stat(filepath.Join(opts.WeightsPath, file.Name())); err != nil {
 			src, err := weights.Open("icon_weights/" + file.Name())
 			if err != nil {
-				Log.Fatal().Err(err).Msg("Error opening file")
+				DWDLog.Fatal().Err(err).Msg("Error opening file")
 			}
 			defer src.Close()
 
 			dest, err := os.Create(filepath.Join(opts.WeightsPath, file.Name()))
 			if err != nil {
-				Log.Fatal().Err(err).Msg("Error creating file")
+				DWDLog.Fatal().Err(err).Msg("Error creating file")
 			}
 			defer dest.Close()
 
@@ -97,20 +100,20 @@ func GenerateWeights(opts WeightOptions) {
 		go func(weightFile string, details WeightsDetails) {
 			defer wg.Done()
 			if _, err := os.Stat(filepath.Join(opts.WeightsPath, weightFile)); err != nil {
-				Log.Info().Msg("Need to generate weights for " + weightFile)
+				DWDLog.Info().Msg("Need to generate weights for " + weightFile)
 
 				dest := filepath.Join(opts.GridsPath, details.GridFile)
 
-				resp, err := http.Get("https://opendata.dwd.de/weather/lib/cdo/" + details.GridFile + ".bz2")
+				body, err := opts.Source.Open(context.Background(), "weather/lib/cdo/"+details.GridFile+".bz2", nil)
 				if err != nil {
-					Log.Error().Err(err).Msg("Error downloading grid file")
+					DWDLog.Error().Err(err).Msg("Error downloading grid file")
 					return
 				}
-				defer resp.Body.Close()
-				bz2Reader := bzip2.NewReader(resp.Body)
+				defer body.Close()
+				bz2Reader := bzip2.NewReader(body)
 				outFile, err := os.Create(dest)
 				if err != nil {
-					Log.Error().Err(err).Msg("Error creating grid file")
+					DWDLog.Error().Err(err).Msg("Error creating grid file")
 					return
 				}
 				defer outFile.Close()
@@ -125,7 +128,7 @@ func GenerateWeights(opts WeightOptions) {
 
 				cmd := exec.Command(opts.CdoPath, args...)
 				if err := cmd.Run(); err != nil {
-					Log.Fatal().Err(err).Msg("Error generating weights")
+					DWDLog.Fatal().Err(err).Msg("Error generating weights")
 				}
 
 			}
@@ -134,5 +137,5 @@ func GenerateWeights(opts WeightOptions) {
 
 	wg.Wait()
 
-	Log.Info().Msg("Weights loaded successfully")
+	DWDLog.Info().Msg("Weights loaded successfully")
 }
@@ -2,14 +2,24 @@ package noaa
 
 import (
 	"bufio"
+	"context"
 	"fmt"
+	"hstin/zephyr/common"
+	. "hstin/zephyr/helper"
+	"hstin/zephyr/source"
+	"hstin/zephyr/tracing"
 	"io"
-	"log"
 	"net/http"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/hstin-de/ndfile"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type NOAAModel struct {
@@ -20,14 +30,19 @@ type NOAAModel struct {
 	res                           string
 	maxStep                       map[int]int
 	breakPoint                    int
+	// Files are read from the NOAA bucket unless a mirror or local source is configured
+	source source.Source
 }
 
+// Public bucket of the NOAA Open Data Dissemination program, the path formats of the models are relative to it
+var noaaBucket = source.NewHTTP("https://noaa-gfs-bdp-pds.s3.amazonaws.com", &http.Client{Timeout: 60 * time.Second})
+
 var noaaModels = map[string]NOAAModel{
 	"gfs": {
 		model:                         "gfs",
 		openDataDeliveryOffsetMinutes: 360,
 		intervalHours:                 6,
-		urlFormat:                     "https://noaa-gfs-bdp-pds.s3.amazonaws.com/gfs.%s/%s/atmos/gfs.t%sz.pgrb2.%s.f%s",
+		urlFormat:                     "gfs.%s/%s/atmos/gfs.t%sz.pgrb2.%s.f%s",
 		res:                           "0p25",
 		maxStep: map[int]int{
 			0:  384,
@@ -36,11 +51,80 @@ var noaaModels = map[string]NOAAModel{
 			18: 384,
 		},
 		breakPoint: 120,
+		source:     noaaBucket,
 	},
 }
 
-var NOAAParameterLookup map[string]string = map[string]string{
-	"temperature": "TMP",
+// NOAAModelOptions overrides the download settings of a model. Unset values are
+// taken from the built-in model with the same remote model name.
+type NOAAModelOptions struct {
+	Model                         string
+	URLFormat                     string
+	OpenDataDeliveryOffsetMinutes int
+	IntervalHours                 int
+	Res                           string
+	MaxStep                       map[int]int
+	BreakPoint                    int
+	// Replaces the source of the model, the NOAA bucket or the server of an absolute URLFormat if nil
+	Source source.Source
+}
+
+// RegisterModel adds or replaces the download settings of a model
+func RegisterModel(name string, opt NOAAModelOptions) error {
+	if opt.Model == "" {
+		opt.Model = name
+	}
+
+	details, ok := noaaModels[opt.Model]
+	if !ok && opt.URLFormat == "" {
+		return fmt.Errorf("unknown NOAA model '%s', url_format is required", opt.Model)
+	}
+
+	details.model = opt.Model
+
+	if opt.URLFormat != "" {
+		// Absolute formats of older configurations are split into the server and the path
+		baseURL, pathFormat, absolute := source.SplitURL(opt.URLFormat)
+		if absolute {
+			details.source = source.NewHTTP(baseURL, &http.Client{Timeout: 60 * time.Second})
+		}
+		details.urlFormat = pathFormat
+	}
+	if opt.Source != nil {
+		details.source = opt.Source
+	}
+	if details.source == nil {
+		details.source = noaaBucket
+	}
+	if opt.OpenDataDeliveryOffsetMinutes != 0 {
+		details.openDataDeliveryOffsetMinutes = opt.OpenDataDeliveryOffsetMinutes
+	}
+	if opt.IntervalHours != 0 {
+		details.intervalHours = opt.IntervalHours
+	}
+	if opt.Res != "" {
+		details.res = opt.Res
+	}
+	if len(opt.MaxStep) != 0 {
+		details.maxStep = opt.MaxStep
+	}
+	if opt.BreakPoint != 0 {
+		details.breakPoint = opt.BreakPoint
+	}
+
+	if details.intervalHours == 0 || len(details.maxStep) == 0 || details.res == "" {
+		return fmt.Errorf("incomplete download settings for NOAA model '%s'", name)
+	}
+
+	noaaModels[name] = details
+
+	return nil
+}
+
+// HasModel reports whether download settings exist for the remote model name
+func HasModel(name string) bool {
+	_, ok := noaaModels[name]
+	return ok
 }
 
 type NOAADownloader struct {
@@ -48,10 +132,11 @@ type NOAADownloader struct {
 	params       []string
 	height       string
 	outputFolder string
+	tmpFolder    string
 	maxStep      int
 	modelDetails NOAAModel
 	fast         bool
-	httpClient   *http.Client
+	scheduler    *common.Scheduler
 }
 
 type NOAADownloaderOptions struct {
@@ -59,9 +144,14 @@ type NOAADownloaderOptions struct {
 	Params       []string
 	Height       string
 	OutputFolder string
+	TmpFolder    string
 	MaxStep      int
 	ModelDetails NOAAModel
 	Fast         bool
+	// Downloaded steps are written to the ND files of the manager
+	NDFileManager *ndfile.NDFileManager
+	Budget        *common.MemoryBudget
+	Scheduler     *common.Scheduler
 }
 
 type IndexData map[string]map[string]struct {
@@ -76,14 +166,16 @@ func NewNOAADownloader(options NOAADownloaderOptions) *NOAADownloader {
 		params:       options.Params,
 		height:       options.Height,
 		outputFolder: options.OutputFolder,
+		tmpFolder:    options.TmpFolder,
 		maxStep:      options.MaxStep,
 		modelDetails: options.ModelDetails,
 		fast:         options.Fast,
-		httpClient:   &http.Client{Timeout: 60 * time.Second},
+		scheduler:    options.Scheduler,
 	}
 }
 
-func (wdp *NOAADownloader) getGribFileUrl(step int, timestamp time.Time) string {
+// getGribFilePath returns the path of the file relative to the root of the source
+func (wdp *NOAADownloader) getGribFilePath(step int, timestamp time.Time) string {
 
 	run := timestamp.Format("20060102")
 	stepStr := fmt.Sprintf("%03d", step)
@@ -97,105 +189,126 @@ func (wdp *NOAADownloader) getMostRecentModelTimestamp() time.Time {
 	return time.Now().UTC().Add(offset).Truncate(time.Duration(wdp.modelDetails.intervalHours) * time.Hour)
 }
 
-func (wdp *NOAADownloader) getIndexFile(url string) (IndexData, error) {
-	resp, err := http.Get(fmt.Sprintf("%s.idx", url))
+func (wdp *NOAADownloader) getIndexFile(ctx context.Context, filePath string) (IndexData, error) {
+	var result IndexData
+
+	err := wdp.scheduler.Fetch(ctx, wdp.modelDetails.source, filePath+".idx", nil, func(body io.Reader) error {
+		var err error
+		result, err = parseIndexFile(body)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("error fetching index: %v", err)
 	}
-	defer resp.Body.Close()
 
-	result := make(IndexData)
-	scanner := bufio.NewScanner(resp.Body)
+	return result, nil
+}
+
+// parseIndexFile reads the byte ranges of the messages from a .idx file. A message ends before
+// the start of the next one, the last message ends at the end of the file.
+func parseIndexFile(r io.Reader) (IndexData, error) {
+	type entry struct {
+		fileType string
+		height   string
+		start    int
+	}
+
+	var entries []entry
+
+	scanner := bufio.NewScanner(r)
 	for scanner.Scan() {
-		line := scanner.Text()
-		parts := strings.Split(line, ":")
+		parts := strings.Split(scanner.Text(), ":")
 
 		if len(parts) < 5 {
 			continue
 		}
 
-		fileType := parts[3]
-		height := parts[4]
-
 		start, err := strconv.Atoi(parts[1])
 		if err != nil {
 			continue
 		}
-		var end int
-		if scanner.Scan() {
-			nextLine := scanner.Text()
-			nextParts := strings.Split(nextLine, ":")
-			if len(nextParts) > 1 {
-				end, err = strconv.Atoi(nextParts[1])
-				if err != nil {
-					continue
-				}
-			}
+
+		entries = append(entries, entry{fileType: parts[3], height: parts[4], start: start})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading index file: %v", err)
+	}
+
+	result := make(IndexData)
+	for i, e := range entries {
+		// Range ends are inclusive, an end before the start reads to the end of the file
+		end := -1
+		if i+1 < len(entries) {
+			end = entries[i+1].start - 1
 		}
 
-		// Store the data
-		if _, ok := result[fileType]; !ok {
-			result[fileType] = make(map[string]struct {
+		if _, ok := result[e.fileType]; !ok {
+			result[e.fileType] = make(map[string]struct {
 				Start int
 				End   int
 			})
 		}
-		result[fileType][height] = struct {
+		result[e.fileType][e.height] = struct {
 			Start int
 			End   int
-		}{start, end}
-	}
-
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading index file: %v", err)
+		}{e.start, end}
 	}
 
 	return result, nil
 }
 
-func (wdp *NOAADownloader) downloadAndProcessFile(url string, index IndexData, param string, retries int) ([]byte, error) {
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("error creating request: %v", err)
+// getLevelName returns the level as written in the .idx files, single-level parameters use the height of the downloader
+func (wdp *NOAADownloader) getLevelName(level common.Level) string {
+	switch level.Type {
+	case common.PRESSURE_LEVEL:
+		return fmt.Sprintf("%d mb", level.Value)
+	case common.HEIGHT_LEVEL:
+		return fmt.Sprintf("%d m above ground", level.Value)
 	}
+	return wdp.height
+}
 
-	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", index[param][wdp.height].Start, index[param][wdp.height].End))
-
-	resp, err := wdp.httpClient.Do(req)
+func (wdp *NOAADownloader) downloadAndProcessFile(ctx context.Context, filePath string, index IndexData, param string, level string) ([]byte, error) {
 
-	if err != nil {
-		if retries > 0 {
-			log.Println("[DL] Retrying...")
-			return wdp.downloadAndProcessFile(url, index, param, retries-1)
-		}
-		return nil, fmt.Errorf("[DL] getting url: %w", err)
+	if _, ok := index[param][level]; !ok {
+		return nil, fmt.Errorf("[DL] %s at level '%s' not found in index", param, level)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusPartialContent {
-		if retries > 0 {
-			log.Println("[DL] Retrying...")
-			return wdp.downloadAndProcessFile(url, index, param, retries-1)
-		}
-	}
+	byteRange := source.Range{Start: int64(index[param][level].Start), End: int64(index[param][level].End)}
+
+	var data []byte
 
-	data, err := io.ReadAll(resp.Body)
+	err := wdp.scheduler.Fetch(ctx, wdp.modelDetails.source, filePath, &byteRange, func(body io.Reader) error {
+		var err error
+		data, err = io.ReadAll(body)
+		return err
+	})
 	if err != nil {
-		return nil, fmt.Errorf("[DL] reading body: %w", err)
+		return nil, fmt.Errorf("[DL] getting %s: %w", filePath, err)
 	}
 
 	return data, nil
 }
 
-func StartNOAADownloader(options NOAADownloaderOptions) (map[string]map[int][]byte, int) {
+// nextRun returns the time the run after run is expected to be available
+func (m NOAAModel) nextRun(run time.Time) time.Time {
+	return run.Add(time.Duration(m.intervalHours)*time.Hour + time.Duration(m.openDataDeliveryOffsetMinutes)*time.Minute)
+}
+
+// StartNOAADownloader downloads the steps of the most recent run and writes every step to the
+// ND files as soon as it and the steps before it are downloaded. The reference time of the run is
+// returned, it is zero if no file was downloaded. complete is true if every expected step of every
+// parameter was written. Every step is spooled to the temp folder, steps of the run that were
+// downloaded by an interrupted download are reused.
+func StartNOAADownloader(ctx context.Context, options NOAADownloaderOptions) (run time.Time, complete bool) {
 	modelDetails, exists := noaaModels[options.ModelName]
 	if !exists {
-		log.Println("[MAIN] Model not found. Available models are:")
+		NOAALog.Error().Msgf("Model %s not found", options.ModelName)
 		for key := range noaaModels {
-			log.Println("-", key)
+			NOAALog.Info().Msg(key)
 		}
-		return nil, 0
+		return time.Time{}, false
 	}
 
 	options.ModelDetails = modelDetails
@@ -211,81 +324,134 @@ func StartNOAADownloader(options NOAADownloaderOptions) (map[string]map[int][]by
 		wdp.maxStep = options.ModelDetails.maxStep[timestamp.Hour()]
 	}
 
-	firstLoop := wdp.maxStep
+	var steps []int
+	for step := 0; step < min(wdp.maxStep, wdp.modelDetails.breakPoint); step++ {
+		steps = append(steps, step)
+	}
+	for step := wdp.modelDetails.breakPoint; step <= wdp.maxStep; step += 3 {
+		steps = append(steps, step)
+	}
+
+	sources := make(map[string]string, len(wdp.params))
+	levels := make(map[string]string, len(wdp.params))
+	streams := make(map[string]*common.ParameterStream, len(wdp.params))
+	params := make([]string, 0, len(wdp.params))
+	complete = true
+
+	for _, param := range wdp.params {
+		source, ok := common.Parameters[param].Source("noaa", wdp.modelName)
+		if !ok {
+			NOAALog.Warn().Msgf("Parameter %s not found. skipping...", param)
+			continue
+		}
 
-	if wdp.maxStep >= wdp.modelDetails.breakPoint {
-		firstLoop = wdp.modelDetails.breakPoint
+		stream, err := common.NewParameterStream(ctx, param, timestamp, steps, options.NDFileManager, options.Budget)
+		if err != nil {
+			NOAALog.Error().Err(err).Msgf("[%s] Could not process %s", wdp.modelName, param)
+			complete = false
+			continue
+		}
+
+		sources[param] = source
+		levels[param] = wdp.getLevelName(common.Parameters[param].Level)
+		streams[param] = stream
+		params = append(params, param)
 	}
 
-	var gribFiles map[string]map[int][]byte = make(map[string]map[int][]byte, wdp.maxStep)
-	var gribFileMutex sync.Mutex
+	spool, err := common.OpenSpool(wdp.tmpFolder, wdp.modelName, timestamp)
+	if err != nil {
+		NOAALog.Warn().Err(err).Msgf("[%s] Could not open spool, downloads can not be resumed", wdp.modelName)
+	}
+	defer spool.Close()
 
-	for step := 0; step < firstLoop; step++ {
-		wg.Add(1)
-		go func(step int, params []string) {
-			defer wg.Done()
+	var downloaded atomic.Int64
 
-			url := wdp.getGribFileUrl(step, timestamp)
+	downloadStep := func(step int, params []string) {
+		defer wg.Done()
 
-			index, err := wdp.getIndexFile(url)
-			if err != nil {
-				log.Println(err)
-				return
+		// Parameters spooled by an interrupted download of the run are not downloaded again
+		missing := make([]string, 0, len(params))
+		for _, param := range params {
+			if data, ok := spool.Get(param, step); ok {
+				streams[param].Add(step, data)
+				downloaded.Add(1)
+			} else {
+				missing = append(missing, param)
 			}
+		}
 
-			for _, param := range params {
+		if len(missing) == 0 {
+			return
+		}
 
-				data, err := wdp.downloadAndProcessFile(url, index, NOAAParameterLookup[param], 5)
-				if err != nil {
-					errors <- err
-					return
-				}
+		filePath := wdp.getGribFilePath(step, timestamp)
 
-				gribFileMutex.Lock()
+		ctx, span := tracing.Start(ctx, "download step",
+			attribute.String("model", wdp.modelName),
+			attribute.Int("step", step),
+			attribute.String("path", filePath),
+		)
+		defer span.End()
 
-				if _, ok := gribFiles[param]; !ok {
-					gribFiles[param] = make(map[int][]byte)
-				}
+		index, err := wdp.getIndexFile(ctx, filePath)
+		if err != nil {
+			NOAALog.Error().Err(err).Int("step", step).Msg("Failed to get index file")
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
 
-				gribFiles[param][step] = data
-				gribFileMutex.Unlock()
+			for _, param := range missing {
+				streams[param].Skip(step)
 			}
-		}(step, wdp.params)
-	}
-
-	for step := wdp.modelDetails.breakPoint; step <= wdp.maxStep; step += 3 {
-		wg.Add(1)
-		go func(step int, params []string) {
-			defer wg.Done()
+			return
+		}
 
-			url := wdp.getGribFileUrl(step, timestamp)
+		for _, param := range missing {
 
-			index, err := wdp.getIndexFile(url)
+			data, err := wdp.downloadAndProcessFile(ctx, filePath, index, sources[param], levels[param])
 			if err != nil {
-				log.Println(err)
-				return
+				span.RecordError(err, trace.WithAttributes(attribute.String("parameter", param)))
+				errors <- err
+				streams[param].Skip(step)
+				continue
 			}
 
-			for _, param := range params {
+			if err := spool.Put(param, step, data); err != nil {
+				NOAALog.Warn().Err(err).Msgf("[%s] Could not spool step %d of %s", wdp.modelName, step, param)
+			}
 
-				data, err := wdp.downloadAndProcessFile(url, index, NOAAParameterLookup[param], 5)
-				if err != nil {
-					errors <- err
-					return
-				}
+			streams[param].Add(step, data)
+			downloaded.Add(1)
+		}
+	}
 
-				gribFileMutex.Lock()
+	// Steps are started in order, so the earliest step a stream is waiting for is always
+	// running while later steps wait for the memory budget or a free worker
+	for _, step := range steps {
+		if err := options.Budget.Wait(ctx); err != nil {
+			break
+		}
 
-				if _, ok := gribFiles[param]; !ok {
-					gribFiles[param] = make(map[int][]byte)
-				}
+		if err := options.Scheduler.Acquire(ctx); err != nil {
+			break
+		}
 
-				gribFiles[param][step] = data
-				gribFileMutex.Unlock()
-			}
-		}(step, wdp.params)
+		wg.Add(1)
+		go func(step int) {
+			defer options.Scheduler.Release()
+			downloadStep(step, params)
+		}(step)
 	}
 
 	wg.Wait()
-	return gribFiles, wdp.modelDetails.breakPoint
+
+	for _, stream := range streams {
+		stream.Close()
+		complete = complete && stream.Complete()
+	}
+
+	if downloaded.Load() == 0 {
+		return time.Time{}, false
+	}
+
+	return timestamp, complete
 }
@@ -0,0 +1,145 @@
+package noaa
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"hstin/zephyr/common"
+	"hstin/zephyr/source"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hstin-de/ndfile"
+)
+
+func TestParseIndexFile(t *testing.T) {
+	index, err := parseIndexFile(strings.NewReader(
+		"1:0:d=2024050100:TMP:surface:anl:\n" +
+			"2:20:d=2024050100:TMP:2 m above ground:anl:\n" +
+			"3:48:d=2024050100:UGRD:10 m above ground:anl:\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		param, level string
+		start, end   int
+	}{
+		{"TMP", "surface", 0, 19},
+		{"TMP", "2 m above ground", 20, 47},
+		// The last message is read to the end of the file
+		{"UGRD", "10 m above ground", 48, -1},
+	}
+
+	for _, tt := range tests {
+		entry, ok := index[tt.param][tt.level]
+		if !ok {
+			t.Errorf("%s at %s not found in index", tt.param, tt.level)
+			continue
+		}
+		if entry.Start != tt.start || entry.End != tt.end {
+			t.Errorf("%s at %s: got bytes %d-%d, want %d-%d", tt.param, tt.level, entry.Start, entry.End, tt.start, tt.end)
+		}
+	}
+}
+
+// The downloaded messages are not valid GRIB messages, so the steps are spooled but can not be
+// decoded and the download of the run is incomplete, with and without the eccodes library
+func TestStartNOAADownloaderLocalSource(t *testing.T) {
+	const model = "fixture-gfs"
+	// Steps up to the break point are hourly, the download ends before MaxStep
+	const maxStep = 2
+
+	sourceDir := t.TempDir()
+	tmpDir := t.TempDir()
+
+	if err := RegisterModel(model, NOAAModelOptions{Model: "gfs", Source: source.NewLocal(sourceDir)}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { delete(noaaModels, model) })
+
+	common.ResetParameters()
+	t.Cleanup(common.ResetParameters)
+
+	parameters := map[string]common.ParameterOptions{
+		"surface_temperature": {Sources: map[string]string{"noaa": "TMP"}},
+		"temperature":         {Sources: map[string]string{"noaa": "TMP"}, Level: common.Level{Type: common.HEIGHT_LEVEL, Value: 2}},
+		"wind_u":              {Sources: map[string]string{"noaa": "UGRD"}, Level: common.Level{Type: common.HEIGHT_LEVEL, Value: 10}},
+	}
+	for name, options := range parameters {
+		if err := common.RegisterParameter(name, options); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fixture, err := os.ReadFile(filepath.Join("testdata", "gfs.pgrb2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	index, err := os.ReadFile(filepath.Join("testdata", "gfs.pgrb2.idx"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Most recent run of gfs, which is delivered 6 hours after the reference time
+	want := time.Now().UTC().Add(-360 * time.Minute).Truncate(6 * time.Hour)
+
+	for step := 0; step < maxStep; step++ {
+		path := filepath.Join(sourceDir, fmt.Sprintf("gfs.%s/%02d/atmos/gfs.t%02dz.pgrb2.0p25.f%03d", want.Format("20060102"), want.Hour(), want.Hour(), step))
+
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, fixture, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path+".idx", index, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run, complete := StartNOAADownloader(context.Background(), NOAADownloaderOptions{
+		ModelName:     model,
+		Params:        []string{"surface_temperature", "temperature", "wind_u"},
+		Height:        "surface",
+		TmpFolder:     tmpDir,
+		MaxStep:       maxStep,
+		NDFileManager: &ndfile.NDFileManager{RootPath: t.TempDir()},
+	})
+
+	if !run.Equal(want) {
+		t.Fatalf("got run %v, want %v", run, want)
+	}
+	if complete {
+		t.Error("got a complete run for steps that can not be decoded")
+	}
+
+	spool, err := common.OpenSpool(tmpDir, model, run)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer spool.Close()
+
+	// Each parameter gets exactly the bytes of its message
+	messages := map[string][]byte{
+		"surface_temperature": fixture[0:20],
+		"temperature":         fixture[20:48],
+		"wind_u":              fixture[48:],
+	}
+
+	for step := 0; step < maxStep; step++ {
+		for param, message := range messages {
+			data, ok := spool.Get(param, step)
+			if !ok {
+				t.Errorf("step %d of %s was not spooled", step, param)
+				continue
+			}
+			if !bytes.Equal(data, message) {
+				t.Errorf("step %d of %s: got %q, want %q", step, param, data, message)
+			}
+		}
+	}
+}
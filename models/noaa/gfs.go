@@ -1,14 +1,17 @@
 package noaa
 
 import (
+	"context"
 	"hstin/zephyr/common"
 	. "hstin/zephyr/helper"
+	"hstin/zephyr/tracing"
 	"os"
 	"path"
 	"strings"
-	"sync"
+	"time"
 
 	"github.com/hstin-de/ndfile"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const (
@@ -21,12 +24,14 @@ type GFSModel struct {
 	ModelName     string
 	NDFileManager *ndfile.NDFileManager
 	ParentModel   common.BaseModel
+	TmpPath       string
 }
 
 type GFSModelOptions struct {
 	RootPath    string
 	ModelName   string
 	ParentModel common.BaseModel
+	TmpPath     string
 }
 
 func NewGFSModel(opt GFSModelOptions) *GFSModel {
@@ -34,7 +39,7 @@ func NewGFSModel(opt GFSModelOptions) *GFSModel {
 	rootPath := path.Join(opt.RootPath, opt.ModelName)
 
 	if os.MkdirAll(rootPath, os.ModePerm) != nil {
-		Log.Fatal().Msgf("Could not create root path for model '%s'", opt.ModelName)
+		NOAALog.Fatal().Msgf("Could not create root path for model '%s'", opt.ModelName)
 	}
 
 	return &GFSModel{
@@ -42,6 +47,7 @@ func NewGFSModel(opt GFSModelOptions) *GFSModel {
 		ModelName:     opt.ModelName,
 		NDFileManager: ndfile.NewNDFileManager(rootPath, TimeIntervalInMinutes),
 		ParentModel:   opt.ParentModel,
+		TmpPath:       path.Join(opt.TmpPath, "noaa"),
 	}
 }
 
@@ -57,7 +63,7 @@ func (m *GFSModel) GetParentModel() common.BaseModel {
 	return m.ParentModel
 }
 
-func (m *GFSModel) DowloadParameter(parameter []string, fast bool) error {
+func (m *GFSModel) DowloadParameter(parameter []string, options common.DownloadOptions) error {
 
 	var downloadParams []string = make([]string, len(parameter))
 
@@ -68,45 +74,66 @@ func (m *GFSModel) DowloadParameter(parameter []string, fast bool) error {
 		}
 	}
 
-	Log.Info().Msg("Downloading parameters: " + strings.Join(downloadParams, ", "))
-
-	var wg sync.WaitGroup
-
-	if fast {
-
-		_, _ = StartNOAADownloader(NOAADownloaderOptions{
-			ModelName: m.ModelName,
-			Params:    downloadParams,
-			MaxStep:   MaxStep,
-			Height:    "surface",
-			Fast:      fast,
+	NOAALog.Info().Msg("Downloading parameters: " + strings.Join(downloadParams, ", "))
+
+	ctx, span := tracing.Start(context.Background(), "download model",
+		attribute.String("model", m.ModelName),
+		attribute.StringSlice("parameters", downloadParams),
+	)
+	defer span.End()
+
+	var run time.Time
+	complete := true
+
+	// Steps are written to the ND files while the download is running
+	download := func(params []string) {
+		downloadedRun, downloadComplete := StartNOAADownloader(ctx, NOAADownloaderOptions{
+			ModelName:     m.ModelName,
+			Params:        params,
+			MaxStep:       MaxStep,
+			Height:        "surface",
+			TmpFolder:     m.TmpPath,
+			Fast:          options.Fast,
+			NDFileManager: m.NDFileManager,
+			Budget:        options.Budget,
+			Scheduler:     options.Scheduler,
 		})
 
-		Log.Info().Msgf("[%s] Download complete. Processing parameters", m.ModelName)
-
-		// for _, p := range downloadParams {
-		// 	wg.Add(1)
-		// 	go m.ProcessParameter(p, downloadedGribFiles, breakPoint, &wg)
-		// }
+		if !downloadedRun.IsZero() {
+			run = downloadedRun
+		}
+		complete = complete && downloadComplete
+	}
 
+	if options.Fast {
+		download(downloadParams)
 	} else {
-
 		for _, p := range downloadParams {
-			downloadedGribFiles, breakPoint := StartNOAADownloader(NOAADownloaderOptions{
-				ModelName: m.ModelName,
-				Params:    []string{p},
-				MaxStep:   MaxStep,
-				Height:    "surface",
-				Fast:      fast,
-			})
-
-			wg.Add(1)
-			common.ProcessParameter(p, downloadedGribFiles, breakPoint, &wg, m.NDFileManager)
+			download([]string{p})
 		}
+	}
+
+	if run.IsZero() {
+		return nil
+	}
 
+	if !complete {
+		// The run info still points to the previous run, the next download of the run resumes from the spool
+		NOAALog.Warn().Msgf("[%s] Download of run %s is incomplete, keeping the spool", m.ModelName, run.Format(time.RFC3339))
+		return nil
 	}
 
-	wg.Wait()
+	NOAALog.Info().Msgf("[%s] Download complete", m.ModelName)
+
+	info := common.RunInfo{Run: run, Ingested: time.Now().UTC(), NextRun: noaaModels[m.ModelName].nextRun(run)}
+	if err := common.WriteRunInfo(m.RootPath, info); err != nil {
+		NOAALog.Warn().Err(err).Msgf("[%s] Could not write run info", m.ModelName)
+	}
+
+	// The ND files of the run are written, the spooled steps are not needed for a resume anymore
+	if err := common.RemoveSpool(m.TmpPath, m.ModelName, run); err != nil {
+		NOAALog.Warn().Err(err).Msgf("[%s] Could not remove spool", m.ModelName)
+	}
 
 	return nil
 
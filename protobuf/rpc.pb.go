@@ -37,6 +37,15 @@ type ForecastResponse struct {
 	Daily           map[string]*structpb.ListValue `protobuf:"bytes,8,rep,name=daily,proto3" json:"daily,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
 	Hourly          map[string]*structpb.ListValue `protobuf:"bytes,9,rep,name=hourly,proto3" json:"hourly,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
 	Minutely15      map[string]*structpb.ListValue `protobuf:"bytes,10,rep,name=minutely15,proto3" json:"minutely15,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	ModelRanges     map[string]*structpb.ListValue `protobuf:"bytes,11,rep,name=model_ranges,json=modelRanges,proto3" json:"model_ranges,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// Only set if condition_format is "object"
+	Conditions *Conditions `protobuf:"bytes,12,opt,name=conditions,proto3" json:"conditions,omitempty"`
+	// Only set if astronomy is requested
+	Astronomy *Astronomy `protobuf:"bytes,13,opt,name=astronomy,proto3" json:"astronomy,omitempty"`
+	// Only set if solar_position is requested
+	SolarPosition *SolarPosition `protobuf:"bytes,14,opt,name=solar_position,json=solarPosition,proto3" json:"solar_position,omitempty"`
+	// Cell of the model grid the values were read from
+	GridCell *GridCell `protobuf:"bytes,15,opt,name=grid_cell,json=gridCell,proto3" json:"grid_cell,omitempty"`
 }
 
 func (x *ForecastResponse) Reset() {
@@ -141,37 +150,582 @@ func (x *ForecastResponse) GetMinutely15() map[string]*structpb.ListValue {
 	return nil
 }
 
+func (x *ForecastResponse) GetModelRanges() map[string]*structpb.ListValue {
+	if x != nil {
+		return x.ModelRanges
+	}
+	return nil
+}
+
+func (x *ForecastResponse) GetConditions() *Conditions {
+	if x != nil {
+		return x.Conditions
+	}
+	return nil
+}
+
+func (x *ForecastResponse) GetAstronomy() *Astronomy {
+	if x != nil {
+		return x.Astronomy
+	}
+	return nil
+}
+
+func (x *ForecastResponse) GetSolarPosition() *SolarPosition {
+	if x != nil {
+		return x.SolarPosition
+	}
+	return nil
+}
+
+func (x *ForecastResponse) GetGridCell() *GridCell {
+	if x != nil {
+		return x.GridCell
+	}
+	return nil
+}
+
+// Condition is the description of a WMO weather code.
+type Condition struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Code int32  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+	Text string `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`
+	Icon string `protobuf:"bytes,3,opt,name=icon,proto3" json:"icon,omitempty"`
+	// False if no model has a value for the step
+	Available bool `protobuf:"varint,4,opt,name=available,proto3" json:"available,omitempty"`
+}
+
+func (x *Condition) Reset() {
+	*x = Condition{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_protobuf_rpc_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Condition) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Condition) ProtoMessage() {}
+
+func (x *Condition) ProtoReflect() protoreflect.Message {
+	mi := &file_protobuf_rpc_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Condition.ProtoReflect.Descriptor instead.
+func (*Condition) Descriptor() ([]byte, []int) {
+	return file_protobuf_rpc_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Condition) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *Condition) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *Condition) GetIcon() string {
+	if x != nil {
+		return x.Icon
+	}
+	return ""
+}
+
+func (x *Condition) GetAvailable() bool {
+	if x != nil {
+		return x.Available
+	}
+	return false
+}
+
+// Astronomy holds one entry per day of the daily series. Times are unix timestamps
+// in milliseconds, 0 if the event does not occur on that day (polar day or night).
+type Astronomy struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Sunrise               []int64 `protobuf:"varint,1,rep,packed,name=sunrise,proto3" json:"sunrise,omitempty"`
+	Sunset                []int64 `protobuf:"varint,2,rep,packed,name=sunset,proto3" json:"sunset,omitempty"`
+	CivilTwilightBegin    []int64 `protobuf:"varint,3,rep,packed,name=civil_twilight_begin,json=civilTwilightBegin,proto3" json:"civil_twilight_begin,omitempty"`
+	CivilTwilightEnd      []int64 `protobuf:"varint,4,rep,packed,name=civil_twilight_end,json=civilTwilightEnd,proto3" json:"civil_twilight_end,omitempty"`
+	NauticalTwilightBegin []int64 `protobuf:"varint,5,rep,packed,name=nautical_twilight_begin,json=nauticalTwilightBegin,proto3" json:"nautical_twilight_begin,omitempty"`
+	NauticalTwilightEnd   []int64 `protobuf:"varint,6,rep,packed,name=nautical_twilight_end,json=nauticalTwilightEnd,proto3" json:"nautical_twilight_end,omitempty"`
+	// Seconds between sunrise and sunset
+	DaylightDuration []float64 `protobuf:"fixed64,7,rep,packed,name=daylight_duration,json=daylightDuration,proto3" json:"daylight_duration,omitempty"`
+	// 0 new moon, 0.25 first quarter, 0.5 full moon, 0.75 last quarter
+	MoonPhase        []float64 `protobuf:"fixed64,8,rep,packed,name=moon_phase,json=moonPhase,proto3" json:"moon_phase,omitempty"`
+	MoonIllumination []float64 `protobuf:"fixed64,9,rep,packed,name=moon_illumination,json=moonIllumination,proto3" json:"moon_illumination,omitempty"`
+}
+
+func (x *Astronomy) Reset() {
+	*x = Astronomy{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_protobuf_rpc_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Astronomy) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Astronomy) ProtoMessage() {}
+
+func (x *Astronomy) ProtoReflect() protoreflect.Message {
+	mi := &file_protobuf_rpc_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Astronomy.ProtoReflect.Descriptor instead.
+func (*Astronomy) Descriptor() ([]byte, []int) {
+	return file_protobuf_rpc_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Astronomy) GetSunrise() []int64 {
+	if x != nil {
+		return x.Sunrise
+	}
+	return nil
+}
+
+func (x *Astronomy) GetSunset() []int64 {
+	if x != nil {
+		return x.Sunset
+	}
+	return nil
+}
+
+func (x *Astronomy) GetCivilTwilightBegin() []int64 {
+	if x != nil {
+		return x.CivilTwilightBegin
+	}
+	return nil
+}
+
+func (x *Astronomy) GetCivilTwilightEnd() []int64 {
+	if x != nil {
+		return x.CivilTwilightEnd
+	}
+	return nil
+}
+
+func (x *Astronomy) GetNauticalTwilightBegin() []int64 {
+	if x != nil {
+		return x.NauticalTwilightBegin
+	}
+	return nil
+}
+
+func (x *Astronomy) GetNauticalTwilightEnd() []int64 {
+	if x != nil {
+		return x.NauticalTwilightEnd
+	}
+	return nil
+}
+
+func (x *Astronomy) GetDaylightDuration() []float64 {
+	if x != nil {
+		return x.DaylightDuration
+	}
+	return nil
+}
+
+func (x *Astronomy) GetMoonPhase() []float64 {
+	if x != nil {
+		return x.MoonPhase
+	}
+	return nil
+}
+
+func (x *Astronomy) GetMoonIllumination() []float64 {
+	if x != nil {
+		return x.MoonIllumination
+	}
+	return nil
+}
+
+// SolarPosition holds the position of the sun in degrees for every hourly step.
+// GridCell is the center of the grid cell that contains the requested coordinates.
+type GridCell struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Model     string  `protobuf:"bytes,1,opt,name=model,proto3" json:"model,omitempty"`
+	Latitude  float64 `protobuf:"fixed64,2,opt,name=latitude,proto3" json:"latitude,omitempty"`
+	Longitude float64 `protobuf:"fixed64,3,opt,name=longitude,proto3" json:"longitude,omitempty"`
+}
+
+func (x *GridCell) Reset() {
+	*x = GridCell{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_protobuf_rpc_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GridCell) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GridCell) ProtoMessage() {}
+
+func (x *GridCell) ProtoReflect() protoreflect.Message {
+	mi := &file_protobuf_rpc_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GridCell.ProtoReflect.Descriptor instead.
+func (*GridCell) Descriptor() ([]byte, []int) {
+	return file_protobuf_rpc_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GridCell) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *GridCell) GetLatitude() float64 {
+	if x != nil {
+		return x.Latitude
+	}
+	return 0
+}
+
+func (x *GridCell) GetLongitude() float64 {
+	if x != nil {
+		return x.Longitude
+	}
+	return 0
+}
+
+type SolarPosition struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Elevation []float64 `protobuf:"fixed64,1,rep,packed,name=elevation,proto3" json:"elevation,omitempty"`
+	Azimuth   []float64 `protobuf:"fixed64,2,rep,packed,name=azimuth,proto3" json:"azimuth,omitempty"`
+}
+
+func (x *SolarPosition) Reset() {
+	*x = SolarPosition{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_protobuf_rpc_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SolarPosition) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SolarPosition) ProtoMessage() {}
+
+func (x *SolarPosition) ProtoReflect() protoreflect.Message {
+	mi := &file_protobuf_rpc_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SolarPosition.ProtoReflect.Descriptor instead.
+func (*SolarPosition) Descriptor() ([]byte, []int) {
+	return file_protobuf_rpc_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *SolarPosition) GetElevation() []float64 {
+	if x != nil {
+		return x.Elevation
+	}
+	return nil
+}
+
+func (x *SolarPosition) GetAzimuth() []float64 {
+	if x != nil {
+		return x.Azimuth
+	}
+	return nil
+}
+
+type Conditions struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Hourly []*Condition `protobuf:"bytes,1,rep,name=hourly,proto3" json:"hourly,omitempty"`
+	Daily  []*Condition `protobuf:"bytes,2,rep,name=daily,proto3" json:"daily,omitempty"`
+}
+
+func (x *Conditions) Reset() {
+	*x = Conditions{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_protobuf_rpc_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Conditions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Conditions) ProtoMessage() {}
+
+func (x *Conditions) ProtoReflect() protoreflect.Message {
+	mi := &file_protobuf_rpc_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Conditions.ProtoReflect.Descriptor instead.
+func (*Conditions) Descriptor() ([]byte, []int) {
+	return file_protobuf_rpc_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Conditions) GetHourly() []*Condition {
+	if x != nil {
+		return x.Hourly
+	}
+	return nil
+}
+
+func (x *Conditions) GetDaily() []*Condition {
+	if x != nil {
+		return x.Daily
+	}
+	return nil
+}
+
 // ForecastRequest is used to pass parameters to the forecast service.
 type ForecastRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Lat          float64  `protobuf:"fixed64,1,opt,name=lat,proto3" json:"lat,omitempty"`
-	Lng          float64  `protobuf:"fixed64,2,opt,name=lng,proto3" json:"lng,omitempty"`
-	ForecastDays int32    `protobuf:"varint,3,opt,name=forecast_days,json=forecastDays,proto3" json:"forecast_days,omitempty"`
-	Minutely15   bool     `protobuf:"varint,4,opt,name=minutely15,proto3" json:"minutely15,omitempty"`
-	Model        string   `protobuf:"bytes,5,opt,name=model,proto3" json:"model,omitempty"`
-	Parameters   []string `protobuf:"bytes,6,rep,name=parameters,proto3" json:"parameters,omitempty"`
+	Lat          float64  `protobuf:"fixed64,1,opt,name=lat,proto3" json:"lat,omitempty"`
+	Lng          float64  `protobuf:"fixed64,2,opt,name=lng,proto3" json:"lng,omitempty"`
+	ForecastDays int32    `protobuf:"varint,3,opt,name=forecast_days,json=forecastDays,proto3" json:"forecast_days,omitempty"`
+	Minutely15   bool     `protobuf:"varint,4,opt,name=minutely15,proto3" json:"minutely15,omitempty"`
+	Model        string   `protobuf:"bytes,5,opt,name=model,proto3" json:"model,omitempty"`
+	Parameters   []string `protobuf:"bytes,6,rep,name=parameters,proto3" json:"parameters,omitempty"`
+	Blend        bool     `protobuf:"varint,7,opt,name=blend,proto3" json:"blend,omitempty"`
+	BlendOverlap int32    `protobuf:"varint,8,opt,name=blend_overlap,json=blendOverlap,proto3" json:"blend_overlap,omitempty"`
+	// "code" (default) or "object"
+	ConditionFormat string `protobuf:"bytes,9,opt,name=condition_format,json=conditionFormat,proto3" json:"condition_format,omitempty"`
+	// Language of the condition texts, "en" (default) or "de"
+	Lang          string `protobuf:"bytes,10,opt,name=lang,proto3" json:"lang,omitempty"`
+	Astronomy     bool   `protobuf:"varint,11,opt,name=astronomy,proto3" json:"astronomy,omitempty"`
+	SolarPosition bool   `protobuf:"varint,12,opt,name=solar_position,json=solarPosition,proto3" json:"solar_position,omitempty"`
+	// Adds global_tilted_irradiance and pv_power to hourly and pv_energy to daily
+	Pv *PVOptions `protobuf:"bytes,13,opt,name=pv,proto3" json:"pv,omitempty"`
+}
+
+func (x *ForecastRequest) Reset() {
+	*x = ForecastRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_protobuf_rpc_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ForecastRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ForecastRequest) ProtoMessage() {}
+
+func (x *ForecastRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_protobuf_rpc_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ForecastRequest.ProtoReflect.Descriptor instead.
+func (*ForecastRequest) Descriptor() ([]byte, []int) {
+	return file_protobuf_rpc_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ForecastRequest) GetLat() float64 {
+	if x != nil {
+		return x.Lat
+	}
+	return 0
+}
+
+func (x *ForecastRequest) GetLng() float64 {
+	if x != nil {
+		return x.Lng
+	}
+	return 0
+}
+
+func (x *ForecastRequest) GetForecastDays() int32 {
+	if x != nil {
+		return x.ForecastDays
+	}
+	return 0
+}
+
+func (x *ForecastRequest) GetMinutely15() bool {
+	if x != nil {
+		return x.Minutely15
+	}
+	return false
+}
+
+func (x *ForecastRequest) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *ForecastRequest) GetParameters() []string {
+	if x != nil {
+		return x.Parameters
+	}
+	return nil
+}
+
+func (x *ForecastRequest) GetBlend() bool {
+	if x != nil {
+		return x.Blend
+	}
+	return false
+}
+
+func (x *ForecastRequest) GetBlendOverlap() int32 {
+	if x != nil {
+		return x.BlendOverlap
+	}
+	return 0
+}
+
+func (x *ForecastRequest) GetConditionFormat() string {
+	if x != nil {
+		return x.ConditionFormat
+	}
+	return ""
+}
+
+func (x *ForecastRequest) GetLang() string {
+	if x != nil {
+		return x.Lang
+	}
+	return ""
+}
+
+func (x *ForecastRequest) GetAstronomy() bool {
+	if x != nil {
+		return x.Astronomy
+	}
+	return false
+}
+
+func (x *ForecastRequest) GetSolarPosition() bool {
+	if x != nil {
+		return x.SolarPosition
+	}
+	return false
+}
+
+func (x *ForecastRequest) GetPv() *PVOptions {
+	if x != nil {
+		return x.Pv
+	}
+	return nil
+}
+
+// PVOptions describes the modules of a PV system.
+type PVOptions struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Angle to the horizontal in degrees
+	Tilt float64 `protobuf:"fixed64,1,opt,name=tilt,proto3" json:"tilt,omitempty"`
+	// Orientation clockwise from north in degrees, 180 = south
+	Azimuth float64 `protobuf:"fixed64,2,opt,name=azimuth,proto3" json:"azimuth,omitempty"`
+	// Peak power in kW
+	Kwp float64 `protobuf:"fixed64,3,opt,name=kwp,proto3" json:"kwp,omitempty"`
 }
 
-func (x *ForecastRequest) Reset() {
-	*x = ForecastRequest{}
+func (x *PVOptions) Reset() {
+	*x = PVOptions{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_protobuf_rpc_proto_msgTypes[1]
+		mi := &file_protobuf_rpc_proto_msgTypes[7]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *ForecastRequest) String() string {
+func (x *PVOptions) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ForecastRequest) ProtoMessage() {}
+func (*PVOptions) ProtoMessage() {}
 
-func (x *ForecastRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_protobuf_rpc_proto_msgTypes[1]
+func (x *PVOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_protobuf_rpc_proto_msgTypes[7]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -182,49 +736,275 @@ func (x *ForecastRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ForecastRequest.ProtoReflect.Descriptor instead.
-func (*ForecastRequest) Descriptor() ([]byte, []int) {
-	return file_protobuf_rpc_proto_rawDescGZIP(), []int{1}
+// Deprecated: Use PVOptions.ProtoReflect.Descriptor instead.
+func (*PVOptions) Descriptor() ([]byte, []int) {
+	return file_protobuf_rpc_proto_rawDescGZIP(), []int{7}
 }
 
-func (x *ForecastRequest) GetLat() float64 {
+func (x *PVOptions) GetTilt() float64 {
 	if x != nil {
-		return x.Lat
+		return x.Tilt
 	}
 	return 0
 }
 
-func (x *ForecastRequest) GetLng() float64 {
+func (x *PVOptions) GetAzimuth() float64 {
 	if x != nil {
-		return x.Lng
+		return x.Azimuth
 	}
 	return 0
 }
 
-func (x *ForecastRequest) GetForecastDays() int32 {
+func (x *PVOptions) GetKwp() float64 {
 	if x != nil {
-		return x.ForecastDays
+		return x.Kwp
 	}
 	return 0
 }
 
-func (x *ForecastRequest) GetMinutely15() bool {
+// GridRequest selects the field of a parameter within a bounding box at one time step.
+type GridRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Parameter string  `protobuf:"bytes,1,opt,name=parameter,proto3" json:"parameter,omitempty"`
+	South     float64 `protobuf:"fixed64,2,opt,name=south,proto3" json:"south,omitempty"`
+	West      float64 `protobuf:"fixed64,3,opt,name=west,proto3" json:"west,omitempty"`
+	North     float64 `protobuf:"fixed64,4,opt,name=north,proto3" json:"north,omitempty"`
+	East      float64 `protobuf:"fixed64,5,opt,name=east,proto3" json:"east,omitempty"`
+	// Unix timestamp in milliseconds, 0 for the current time
+	Time int64 `protobuf:"varint,6,opt,name=time,proto3" json:"time,omitempty"`
+	// Empty to select the model at the center of the box
+	Model string `protobuf:"bytes,7,opt,name=model,proto3" json:"model,omitempty"`
+	// Only every stride-th row and column is returned
+	Stride int32 `protobuf:"varint,8,opt,name=stride,proto3" json:"stride,omitempty"`
+}
+
+func (x *GridRequest) Reset() {
+	*x = GridRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_protobuf_rpc_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GridRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GridRequest) ProtoMessage() {}
+
+func (x *GridRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_protobuf_rpc_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GridRequest.ProtoReflect.Descriptor instead.
+func (*GridRequest) Descriptor() ([]byte, []int) {
+	return file_protobuf_rpc_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GridRequest) GetParameter() string {
 	if x != nil {
-		return x.Minutely15
+		return x.Parameter
 	}
-	return false
+	return ""
 }
 
-func (x *ForecastRequest) GetModel() string {
+func (x *GridRequest) GetSouth() float64 {
+	if x != nil {
+		return x.South
+	}
+	return 0
+}
+
+func (x *GridRequest) GetWest() float64 {
+	if x != nil {
+		return x.West
+	}
+	return 0
+}
+
+func (x *GridRequest) GetNorth() float64 {
+	if x != nil {
+		return x.North
+	}
+	return 0
+}
+
+func (x *GridRequest) GetEast() float64 {
+	if x != nil {
+		return x.East
+	}
+	return 0
+}
+
+func (x *GridRequest) GetTime() int64 {
+	if x != nil {
+		return x.Time
+	}
+	return 0
+}
+
+func (x *GridRequest) GetModel() string {
 	if x != nil {
 		return x.Model
 	}
 	return ""
 }
 
-func (x *ForecastRequest) GetParameters() []string {
+func (x *GridRequest) GetStride() int32 {
 	if x != nil {
-		return x.Parameters
+		return x.Stride
+	}
+	return 0
+}
+
+// GridResponse holds the values row by row from south to north, every row from west to east.
+type GridResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Model     string `protobuf:"bytes,1,opt,name=model,proto3" json:"model,omitempty"`
+	Parameter string `protobuf:"bytes,2,opt,name=parameter,proto3" json:"parameter,omitempty"`
+	Unit      string `protobuf:"bytes,3,opt,name=unit,proto3" json:"unit,omitempty"`
+	// Unix timestamp of the step in milliseconds
+	Time int64 `protobuf:"varint,4,opt,name=time,proto3" json:"time,omitempty"`
+	// Center of the south western cell
+	LatOrigin float64 `protobuf:"fixed64,5,opt,name=lat_origin,json=latOrigin,proto3" json:"lat_origin,omitempty"`
+	LngOrigin float64 `protobuf:"fixed64,6,opt,name=lng_origin,json=lngOrigin,proto3" json:"lng_origin,omitempty"`
+	Dx        float64 `protobuf:"fixed64,7,opt,name=dx,proto3" json:"dx,omitempty"`
+	Dy        float64 `protobuf:"fixed64,8,opt,name=dy,proto3" json:"dy,omitempty"`
+	Nx        int32   `protobuf:"varint,9,opt,name=nx,proto3" json:"nx,omitempty"`
+	Ny        int32   `protobuf:"varint,10,opt,name=ny,proto3" json:"ny,omitempty"`
+	// Little endian float32 values, NaN if missing
+	Values []byte `protobuf:"bytes,11,opt,name=values,proto3" json:"values,omitempty"`
+	// One bit per value, least significant bit first, set if the value is present
+	Mask []byte `protobuf:"bytes,12,opt,name=mask,proto3" json:"mask,omitempty"`
+}
+
+func (x *GridResponse) Reset() {
+	*x = GridResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_protobuf_rpc_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GridResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GridResponse) ProtoMessage() {}
+
+func (x *GridResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_protobuf_rpc_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GridResponse.ProtoReflect.Descriptor instead.
+func (*GridResponse) Descriptor() ([]byte, []int) {
+	return file_protobuf_rpc_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *GridResponse) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *GridResponse) GetParameter() string {
+	if x != nil {
+		return x.Parameter
+	}
+	return ""
+}
+
+func (x *GridResponse) GetUnit() string {
+	if x != nil {
+		return x.Unit
+	}
+	return ""
+}
+
+func (x *GridResponse) GetTime() int64 {
+	if x != nil {
+		return x.Time
+	}
+	return 0
+}
+
+func (x *GridResponse) GetLatOrigin() float64 {
+	if x != nil {
+		return x.LatOrigin
+	}
+	return 0
+}
+
+func (x *GridResponse) GetLngOrigin() float64 {
+	if x != nil {
+		return x.LngOrigin
+	}
+	return 0
+}
+
+func (x *GridResponse) GetDx() float64 {
+	if x != nil {
+		return x.Dx
+	}
+	return 0
+}
+
+func (x *GridResponse) GetDy() float64 {
+	if x != nil {
+		return x.Dy
+	}
+	return 0
+}
+
+func (x *GridResponse) GetNx() int32 {
+	if x != nil {
+		return x.Nx
+	}
+	return 0
+}
+
+func (x *GridResponse) GetNy() int32 {
+	if x != nil {
+		return x.Ny
+	}
+	return 0
+}
+
+func (x *GridResponse) GetValues() []byte {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+func (x *GridResponse) GetMask() []byte {
+	if x != nil {
+		return x.Mask
 	}
 	return nil
 }
@@ -235,7 +1015,7 @@ var file_protobuf_rpc_proto_rawDesc = []byte{
 	0x0a, 0x12, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x72, 0x70, 0x63, 0x2e, 0x70,
 	0x72, 0x6f, 0x74, 0x6f, 0x12, 0x08, 0x66, 0x6f, 0x72, 0x65, 0x63, 0x61, 0x73, 0x74, 0x1a, 0x1c,
 	0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f,
-	0x73, 0x74, 0x72, 0x75, 0x63, 0x74, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xca, 0x06, 0x0a,
+	0x73, 0x74, 0x72, 0x75, 0x63, 0x74, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xd0, 0x09, 0x0a,
 	0x10, 0x46, 0x6f, 0x72, 0x65, 0x63, 0x61, 0x73, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
 	0x65, 0x12, 0x29, 0x0a, 0x10, 0x63, 0x61, 0x6c, 0x63, 0x75, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e,
 	0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0f, 0x63, 0x61, 0x6c,
@@ -266,48 +1046,172 @@ var file_protobuf_rpc_proto_rawDesc = []byte{
 	0x2e, 0x66, 0x6f, 0x72, 0x65, 0x63, 0x61, 0x73, 0x74, 0x2e, 0x46, 0x6f, 0x72, 0x65, 0x63, 0x61,
 	0x73, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2e, 0x4d, 0x69, 0x6e, 0x75, 0x74,
 	0x65, 0x6c, 0x79, 0x31, 0x35, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0a, 0x6d, 0x69, 0x6e, 0x75,
-	0x74, 0x65, 0x6c, 0x79, 0x31, 0x35, 0x1a, 0x59, 0x0a, 0x0f, 0x55, 0x73, 0x65, 0x64, 0x4d, 0x6f,
-	0x64, 0x65, 0x6c, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79,
+	0x74, 0x65, 0x6c, 0x79, 0x31, 0x35, 0x12, 0x4e, 0x0a, 0x0c, 0x6d, 0x6f, 0x64, 0x65, 0x6c, 0x5f,
+	0x72, 0x61, 0x6e, 0x67, 0x65, 0x73, 0x18, 0x0b, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x2b, 0x2e, 0x66,
+	0x6f, 0x72, 0x65, 0x63, 0x61, 0x73, 0x74, 0x2e, 0x46, 0x6f, 0x72, 0x65, 0x63, 0x61, 0x73, 0x74,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2e, 0x4d, 0x6f, 0x64, 0x65, 0x6c, 0x52, 0x61,
+	0x6e, 0x67, 0x65, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0b, 0x6d, 0x6f, 0x64, 0x65, 0x6c,
+	0x52, 0x61, 0x6e, 0x67, 0x65, 0x73, 0x12, 0x34, 0x0a, 0x0a, 0x63, 0x6f, 0x6e, 0x64, 0x69, 0x74,
+	0x69, 0x6f, 0x6e, 0x73, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x66, 0x6f, 0x72,
+	0x65, 0x63, 0x61, 0x73, 0x74, 0x2e, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x73,
+	0x52, 0x0a, 0x63, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x31, 0x0a, 0x09,
+	0x61, 0x73, 0x74, 0x72, 0x6f, 0x6e, 0x6f, 0x6d, 0x79, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x13, 0x2e, 0x66, 0x6f, 0x72, 0x65, 0x63, 0x61, 0x73, 0x74, 0x2e, 0x41, 0x73, 0x74, 0x72, 0x6f,
+	0x6e, 0x6f, 0x6d, 0x79, 0x52, 0x09, 0x61, 0x73, 0x74, 0x72, 0x6f, 0x6e, 0x6f, 0x6d, 0x79, 0x12,
+	0x3e, 0x0a, 0x0e, 0x73, 0x6f, 0x6c, 0x61, 0x72, 0x5f, 0x70, 0x6f, 0x73, 0x69, 0x74, 0x69, 0x6f,
+	0x6e, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x66, 0x6f, 0x72, 0x65, 0x63, 0x61,
+	0x73, 0x74, 0x2e, 0x53, 0x6f, 0x6c, 0x61, 0x72, 0x50, 0x6f, 0x73, 0x69, 0x74, 0x69, 0x6f, 0x6e,
+	0x52, 0x0d, 0x73, 0x6f, 0x6c, 0x61, 0x72, 0x50, 0x6f, 0x73, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x12,
+	0x2f, 0x0a, 0x09, 0x67, 0x72, 0x69, 0x64, 0x5f, 0x63, 0x65, 0x6c, 0x6c, 0x18, 0x0f, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x12, 0x2e, 0x66, 0x6f, 0x72, 0x65, 0x63, 0x61, 0x73, 0x74, 0x2e, 0x47, 0x72,
+	0x69, 0x64, 0x43, 0x65, 0x6c, 0x6c, 0x52, 0x08, 0x67, 0x72, 0x69, 0x64, 0x43, 0x65, 0x6c, 0x6c,
+	0x1a, 0x59, 0x0a, 0x0f, 0x55, 0x73, 0x65, 0x64, 0x4d, 0x6f, 0x64, 0x65, 0x6c, 0x73, 0x45, 0x6e,
+	0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x30, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x56, 0x61, 0x6c, 0x75, 0x65,
+	0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x54, 0x0a, 0x0a, 0x44,
+	0x61, 0x69, 0x6c, 0x79, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79,
 	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x30, 0x0a, 0x05, 0x76,
 	0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f,
 	0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x4c, 0x69, 0x73,
 	0x74, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38,
-	0x01, 0x1a, 0x54, 0x0a, 0x0a, 0x44, 0x61, 0x69, 0x6c, 0x79, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12,
-	0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65,
-	0x79, 0x12, 0x30, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b,
-	0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
-	0x75, 0x66, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x52, 0x05, 0x76, 0x61,
-	0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x55, 0x0a, 0x0b, 0x48, 0x6f, 0x75, 0x72, 0x6c,
-	0x79, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20,
-	0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x30, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75,
-	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
-	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x56, 0x61,
-	0x6c, 0x75, 0x65, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x59,
-	0x0a, 0x0f, 0x4d, 0x69, 0x6e, 0x75, 0x74, 0x65, 0x6c, 0x79, 0x31, 0x35, 0x45, 0x6e, 0x74, 0x72,
-	0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03,
-	0x6b, 0x65, 0x79, 0x12, 0x30, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01,
-	0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74,
-	0x6f, 0x62, 0x75, 0x66, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x52, 0x05,
-	0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0xb0, 0x01, 0x0a, 0x0f, 0x46, 0x6f,
-	0x72, 0x65, 0x63, 0x61, 0x73, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x10, 0x0a,
-	0x03, 0x6c, 0x61, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x01, 0x52, 0x03, 0x6c, 0x61, 0x74, 0x12,
-	0x10, 0x0a, 0x03, 0x6c, 0x6e, 0x67, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x03, 0x6c, 0x6e,
-	0x67, 0x12, 0x23, 0x0a, 0x0d, 0x66, 0x6f, 0x72, 0x65, 0x63, 0x61, 0x73, 0x74, 0x5f, 0x64, 0x61,
-	0x79, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0c, 0x66, 0x6f, 0x72, 0x65, 0x63, 0x61,
-	0x73, 0x74, 0x44, 0x61, 0x79, 0x73, 0x12, 0x1e, 0x0a, 0x0a, 0x6d, 0x69, 0x6e, 0x75, 0x74, 0x65,
-	0x6c, 0x79, 0x31, 0x35, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0a, 0x6d, 0x69, 0x6e, 0x75,
-	0x74, 0x65, 0x6c, 0x79, 0x31, 0x35, 0x12, 0x14, 0x0a, 0x05, 0x6d, 0x6f, 0x64, 0x65, 0x6c, 0x18,
-	0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6d, 0x6f, 0x64, 0x65, 0x6c, 0x12, 0x1e, 0x0a, 0x0a,
-	0x70, 0x61, 0x72, 0x61, 0x6d, 0x65, 0x74, 0x65, 0x72, 0x73, 0x18, 0x06, 0x20, 0x03, 0x28, 0x09,
-	0x52, 0x0a, 0x70, 0x61, 0x72, 0x61, 0x6d, 0x65, 0x74, 0x65, 0x72, 0x73, 0x32, 0x59, 0x0a, 0x0f,
-	0x46, 0x6f, 0x72, 0x65, 0x63, 0x61, 0x73, 0x74, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12,
-	0x46, 0x0a, 0x0b, 0x47, 0x65, 0x74, 0x46, 0x6f, 0x72, 0x65, 0x63, 0x61, 0x73, 0x74, 0x12, 0x19,
-	0x2e, 0x66, 0x6f, 0x72, 0x65, 0x63, 0x61, 0x73, 0x74, 0x2e, 0x46, 0x6f, 0x72, 0x65, 0x63, 0x61,
-	0x73, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1a, 0x2e, 0x66, 0x6f, 0x72, 0x65,
-	0x63, 0x61, 0x73, 0x74, 0x2e, 0x46, 0x6f, 0x72, 0x65, 0x63, 0x61, 0x73, 0x74, 0x52, 0x65, 0x73,
-	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x42, 0x17, 0x5a, 0x15, 0x68, 0x73, 0x74, 0x69, 0x6e,
-	0x2f, 0x7a, 0x65, 0x70, 0x68, 0x79, 0x72, 0x3b, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
-	0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x01, 0x1a, 0x55, 0x0a, 0x0b, 0x48, 0x6f, 0x75, 0x72, 0x6c, 0x79, 0x45, 0x6e, 0x74, 0x72, 0x79,
+	0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b,
+	0x65, 0x79, 0x12, 0x30, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x75, 0x66, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x52, 0x05, 0x76,
+	0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x59, 0x0a, 0x0f, 0x4d, 0x69, 0x6e, 0x75,
+	0x74, 0x65, 0x6c, 0x79, 0x31, 0x35, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b,
+	0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x30, 0x0a,
+	0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67,
+	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x4c,
+	0x69, 0x73, 0x74, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a,
+	0x02, 0x38, 0x01, 0x1a, 0x5a, 0x0a, 0x10, 0x4d, 0x6f, 0x64, 0x65, 0x6c, 0x52, 0x61, 0x6e, 0x67,
+	0x65, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x30, 0x0a, 0x05, 0x76, 0x61, 0x6c,
+	0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c,
+	0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x56,
+	0x61, 0x6c, 0x75, 0x65, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22,
+	0x65, 0x0a, 0x09, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x12, 0x0a, 0x04,
+	0x63, 0x6f, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x63, 0x6f, 0x64, 0x65,
+	0x12, 0x12, 0x0a, 0x04, 0x74, 0x65, 0x78, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04,
+	0x74, 0x65, 0x78, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x69, 0x63, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x04, 0x69, 0x63, 0x6f, 0x6e, 0x12, 0x1c, 0x0a, 0x09, 0x61, 0x76, 0x61, 0x69,
+	0x6c, 0x61, 0x62, 0x6c, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x61, 0x76, 0x61,
+	0x69, 0x6c, 0x61, 0x62, 0x6c, 0x65, 0x22, 0x82, 0x03, 0x0a, 0x09, 0x41, 0x73, 0x74, 0x72, 0x6f,
+	0x6e, 0x6f, 0x6d, 0x79, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x75, 0x6e, 0x72, 0x69, 0x73, 0x65, 0x18,
+	0x01, 0x20, 0x03, 0x28, 0x03, 0x52, 0x07, 0x73, 0x75, 0x6e, 0x72, 0x69, 0x73, 0x65, 0x12, 0x16,
+	0x0a, 0x06, 0x73, 0x75, 0x6e, 0x73, 0x65, 0x74, 0x18, 0x02, 0x20, 0x03, 0x28, 0x03, 0x52, 0x06,
+	0x73, 0x75, 0x6e, 0x73, 0x65, 0x74, 0x12, 0x30, 0x0a, 0x14, 0x63, 0x69, 0x76, 0x69, 0x6c, 0x5f,
+	0x74, 0x77, 0x69, 0x6c, 0x69, 0x67, 0x68, 0x74, 0x5f, 0x62, 0x65, 0x67, 0x69, 0x6e, 0x18, 0x03,
+	0x20, 0x03, 0x28, 0x03, 0x52, 0x12, 0x63, 0x69, 0x76, 0x69, 0x6c, 0x54, 0x77, 0x69, 0x6c, 0x69,
+	0x67, 0x68, 0x74, 0x42, 0x65, 0x67, 0x69, 0x6e, 0x12, 0x2c, 0x0a, 0x12, 0x63, 0x69, 0x76, 0x69,
+	0x6c, 0x5f, 0x74, 0x77, 0x69, 0x6c, 0x69, 0x67, 0x68, 0x74, 0x5f, 0x65, 0x6e, 0x64, 0x18, 0x04,
+	0x20, 0x03, 0x28, 0x03, 0x52, 0x10, 0x63, 0x69, 0x76, 0x69, 0x6c, 0x54, 0x77, 0x69, 0x6c, 0x69,
+	0x67, 0x68, 0x74, 0x45, 0x6e, 0x64, 0x12, 0x36, 0x0a, 0x17, 0x6e, 0x61, 0x75, 0x74, 0x69, 0x63,
+	0x61, 0x6c, 0x5f, 0x74, 0x77, 0x69, 0x6c, 0x69, 0x67, 0x68, 0x74, 0x5f, 0x62, 0x65, 0x67, 0x69,
+	0x6e, 0x18, 0x05, 0x20, 0x03, 0x28, 0x03, 0x52, 0x15, 0x6e, 0x61, 0x75, 0x74, 0x69, 0x63, 0x61,
+	0x6c, 0x54, 0x77, 0x69, 0x6c, 0x69, 0x67, 0x68, 0x74, 0x42, 0x65, 0x67, 0x69, 0x6e, 0x12, 0x32,
+	0x0a, 0x15, 0x6e, 0x61, 0x75, 0x74, 0x69, 0x63, 0x61, 0x6c, 0x5f, 0x74, 0x77, 0x69, 0x6c, 0x69,
+	0x67, 0x68, 0x74, 0x5f, 0x65, 0x6e, 0x64, 0x18, 0x06, 0x20, 0x03, 0x28, 0x03, 0x52, 0x13, 0x6e,
+	0x61, 0x75, 0x74, 0x69, 0x63, 0x61, 0x6c, 0x54, 0x77, 0x69, 0x6c, 0x69, 0x67, 0x68, 0x74, 0x45,
+	0x6e, 0x64, 0x12, 0x2b, 0x0a, 0x11, 0x64, 0x61, 0x79, 0x6c, 0x69, 0x67, 0x68, 0x74, 0x5f, 0x64,
+	0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x07, 0x20, 0x03, 0x28, 0x01, 0x52, 0x10, 0x64,
+	0x61, 0x79, 0x6c, 0x69, 0x67, 0x68, 0x74, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12,
+	0x1d, 0x0a, 0x0a, 0x6d, 0x6f, 0x6f, 0x6e, 0x5f, 0x70, 0x68, 0x61, 0x73, 0x65, 0x18, 0x08, 0x20,
+	0x03, 0x28, 0x01, 0x52, 0x09, 0x6d, 0x6f, 0x6f, 0x6e, 0x50, 0x68, 0x61, 0x73, 0x65, 0x12, 0x2b,
+	0x0a, 0x11, 0x6d, 0x6f, 0x6f, 0x6e, 0x5f, 0x69, 0x6c, 0x6c, 0x75, 0x6d, 0x69, 0x6e, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x18, 0x09, 0x20, 0x03, 0x28, 0x01, 0x52, 0x10, 0x6d, 0x6f, 0x6f, 0x6e, 0x49,
+	0x6c, 0x6c, 0x75, 0x6d, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x5a, 0x0a, 0x08, 0x47,
+	0x72, 0x69, 0x64, 0x43, 0x65, 0x6c, 0x6c, 0x12, 0x14, 0x0a, 0x05, 0x6d, 0x6f, 0x64, 0x65, 0x6c,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6d, 0x6f, 0x64, 0x65, 0x6c, 0x12, 0x1a, 0x0a,
+	0x08, 0x6c, 0x61, 0x74, 0x69, 0x74, 0x75, 0x64, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52,
+	0x08, 0x6c, 0x61, 0x74, 0x69, 0x74, 0x75, 0x64, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x6c, 0x6f, 0x6e,
+	0x67, 0x69, 0x74, 0x75, 0x64, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x52, 0x09, 0x6c, 0x6f,
+	0x6e, 0x67, 0x69, 0x74, 0x75, 0x64, 0x65, 0x22, 0x47, 0x0a, 0x0d, 0x53, 0x6f, 0x6c, 0x61, 0x72,
+	0x50, 0x6f, 0x73, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1c, 0x0a, 0x09, 0x65, 0x6c, 0x65, 0x76,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x03, 0x28, 0x01, 0x52, 0x09, 0x65, 0x6c, 0x65,
+	0x76, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x18, 0x0a, 0x07, 0x61, 0x7a, 0x69, 0x6d, 0x75, 0x74,
+	0x68, 0x18, 0x02, 0x20, 0x03, 0x28, 0x01, 0x52, 0x07, 0x61, 0x7a, 0x69, 0x6d, 0x75, 0x74, 0x68,
+	0x22, 0x64, 0x0a, 0x0a, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x2b,
+	0x0a, 0x06, 0x68, 0x6f, 0x75, 0x72, 0x6c, 0x79, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x13,
+	0x2e, 0x66, 0x6f, 0x72, 0x65, 0x63, 0x61, 0x73, 0x74, 0x2e, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74,
+	0x69, 0x6f, 0x6e, 0x52, 0x06, 0x68, 0x6f, 0x75, 0x72, 0x6c, 0x79, 0x12, 0x29, 0x0a, 0x05, 0x64,
+	0x61, 0x69, 0x6c, 0x79, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x66, 0x6f, 0x72,
+	0x65, 0x63, 0x61, 0x73, 0x74, 0x2e, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x52,
+	0x05, 0x64, 0x61, 0x69, 0x6c, 0x79, 0x22, 0x94, 0x03, 0x0a, 0x0f, 0x46, 0x6f, 0x72, 0x65, 0x63,
+	0x61, 0x73, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x6c, 0x61,
+	0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x01, 0x52, 0x03, 0x6c, 0x61, 0x74, 0x12, 0x10, 0x0a, 0x03,
+	0x6c, 0x6e, 0x67, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x03, 0x6c, 0x6e, 0x67, 0x12, 0x23,
+	0x0a, 0x0d, 0x66, 0x6f, 0x72, 0x65, 0x63, 0x61, 0x73, 0x74, 0x5f, 0x64, 0x61, 0x79, 0x73, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0c, 0x66, 0x6f, 0x72, 0x65, 0x63, 0x61, 0x73, 0x74, 0x44,
+	0x61, 0x79, 0x73, 0x12, 0x1e, 0x0a, 0x0a, 0x6d, 0x69, 0x6e, 0x75, 0x74, 0x65, 0x6c, 0x79, 0x31,
+	0x35, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0a, 0x6d, 0x69, 0x6e, 0x75, 0x74, 0x65, 0x6c,
+	0x79, 0x31, 0x35, 0x12, 0x14, 0x0a, 0x05, 0x6d, 0x6f, 0x64, 0x65, 0x6c, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x05, 0x6d, 0x6f, 0x64, 0x65, 0x6c, 0x12, 0x1e, 0x0a, 0x0a, 0x70, 0x61, 0x72,
+	0x61, 0x6d, 0x65, 0x74, 0x65, 0x72, 0x73, 0x18, 0x06, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0a, 0x70,
+	0x61, 0x72, 0x61, 0x6d, 0x65, 0x74, 0x65, 0x72, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x62, 0x6c, 0x65,
+	0x6e, 0x64, 0x18, 0x07, 0x20, 0x01, 0x28, 0x08, 0x52, 0x05, 0x62, 0x6c, 0x65, 0x6e, 0x64, 0x12,
+	0x23, 0x0a, 0x0d, 0x62, 0x6c, 0x65, 0x6e, 0x64, 0x5f, 0x6f, 0x76, 0x65, 0x72, 0x6c, 0x61, 0x70,
+	0x18, 0x08, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0c, 0x62, 0x6c, 0x65, 0x6e, 0x64, 0x4f, 0x76, 0x65,
+	0x72, 0x6c, 0x61, 0x70, 0x12, 0x29, 0x0a, 0x10, 0x63, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f,
+	0x6e, 0x5f, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x18, 0x09, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f,
+	0x63, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x12,
+	0x12, 0x0a, 0x04, 0x6c, 0x61, 0x6e, 0x67, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6c,
+	0x61, 0x6e, 0x67, 0x12, 0x1c, 0x0a, 0x09, 0x61, 0x73, 0x74, 0x72, 0x6f, 0x6e, 0x6f, 0x6d, 0x79,
+	0x18, 0x0b, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x61, 0x73, 0x74, 0x72, 0x6f, 0x6e, 0x6f, 0x6d,
+	0x79, 0x12, 0x25, 0x0a, 0x0e, 0x73, 0x6f, 0x6c, 0x61, 0x72, 0x5f, 0x70, 0x6f, 0x73, 0x69, 0x74,
+	0x69, 0x6f, 0x6e, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0d, 0x73, 0x6f, 0x6c, 0x61, 0x72,
+	0x50, 0x6f, 0x73, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x23, 0x0a, 0x02, 0x70, 0x76, 0x18, 0x0d,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x66, 0x6f, 0x72, 0x65, 0x63, 0x61, 0x73, 0x74, 0x2e,
+	0x50, 0x56, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x02, 0x70, 0x76, 0x22, 0x4b, 0x0a,
+	0x09, 0x50, 0x56, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x69,
+	0x6c, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x01, 0x52, 0x04, 0x74, 0x69, 0x6c, 0x74, 0x12, 0x18,
+	0x0a, 0x07, 0x61, 0x7a, 0x69, 0x6d, 0x75, 0x74, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52,
+	0x07, 0x61, 0x7a, 0x69, 0x6d, 0x75, 0x74, 0x68, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x77, 0x70, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x01, 0x52, 0x03, 0x6b, 0x77, 0x70, 0x22, 0xc1, 0x01, 0x0a, 0x0b, 0x47,
+	0x72, 0x69, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1c, 0x0a, 0x09, 0x70, 0x61,
+	0x72, 0x61, 0x6d, 0x65, 0x74, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x70,
+	0x61, 0x72, 0x61, 0x6d, 0x65, 0x74, 0x65, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x6f, 0x75, 0x74,
+	0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x73, 0x6f, 0x75, 0x74, 0x68, 0x12, 0x12,
+	0x0a, 0x04, 0x77, 0x65, 0x73, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x52, 0x04, 0x77, 0x65,
+	0x73, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x6e, 0x6f, 0x72, 0x74, 0x68, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x01, 0x52, 0x05, 0x6e, 0x6f, 0x72, 0x74, 0x68, 0x12, 0x12, 0x0a, 0x04, 0x65, 0x61, 0x73, 0x74,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x01, 0x52, 0x04, 0x65, 0x61, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04,
+	0x74, 0x69, 0x6d, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x74, 0x69, 0x6d, 0x65,
+	0x12, 0x14, 0x0a, 0x05, 0x6d, 0x6f, 0x64, 0x65, 0x6c, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x05, 0x6d, 0x6f, 0x64, 0x65, 0x6c, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x74, 0x72, 0x69, 0x64, 0x65,
+	0x18, 0x08, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x73, 0x74, 0x72, 0x69, 0x64, 0x65, 0x22, 0x94,
+	0x02, 0x0a, 0x0c, 0x47, 0x72, 0x69, 0x64, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
+	0x14, 0x0a, 0x05, 0x6d, 0x6f, 0x64, 0x65, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05,
+	0x6d, 0x6f, 0x64, 0x65, 0x6c, 0x12, 0x1c, 0x0a, 0x09, 0x70, 0x61, 0x72, 0x61, 0x6d, 0x65, 0x74,
+	0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x70, 0x61, 0x72, 0x61, 0x6d, 0x65,
+	0x74, 0x65, 0x72, 0x12, 0x12, 0x0a, 0x04, 0x75, 0x6e, 0x69, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x04, 0x75, 0x6e, 0x69, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x69, 0x6d, 0x65, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x74, 0x69, 0x6d, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x6c,
+	0x61, 0x74, 0x5f, 0x6f, 0x72, 0x69, 0x67, 0x69, 0x6e, 0x18, 0x05, 0x20, 0x01, 0x28, 0x01, 0x52,
+	0x09, 0x6c, 0x61, 0x74, 0x4f, 0x72, 0x69, 0x67, 0x69, 0x6e, 0x12, 0x1d, 0x0a, 0x0a, 0x6c, 0x6e,
+	0x67, 0x5f, 0x6f, 0x72, 0x69, 0x67, 0x69, 0x6e, 0x18, 0x06, 0x20, 0x01, 0x28, 0x01, 0x52, 0x09,
+	0x6c, 0x6e, 0x67, 0x4f, 0x72, 0x69, 0x67, 0x69, 0x6e, 0x12, 0x0e, 0x0a, 0x02, 0x64, 0x78, 0x18,
+	0x07, 0x20, 0x01, 0x28, 0x01, 0x52, 0x02, 0x64, 0x78, 0x12, 0x0e, 0x0a, 0x02, 0x64, 0x79, 0x18,
+	0x08, 0x20, 0x01, 0x28, 0x01, 0x52, 0x02, 0x64, 0x79, 0x12, 0x0e, 0x0a, 0x02, 0x6e, 0x78, 0x18,
+	0x09, 0x20, 0x01, 0x28, 0x05, 0x52, 0x02, 0x6e, 0x78, 0x12, 0x0e, 0x0a, 0x02, 0x6e, 0x79, 0x18,
+	0x0a, 0x20, 0x01, 0x28, 0x05, 0x52, 0x02, 0x6e, 0x79, 0x12, 0x16, 0x0a, 0x06, 0x76, 0x61, 0x6c,
+	0x75, 0x65, 0x73, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x76, 0x61, 0x6c, 0x75, 0x65,
+	0x73, 0x12, 0x12, 0x0a, 0x04, 0x6d, 0x61, 0x73, 0x6b, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x0c, 0x52,
+	0x04, 0x6d, 0x61, 0x73, 0x6b, 0x32, 0x95, 0x01, 0x0a, 0x0f, 0x46, 0x6f, 0x72, 0x65, 0x63, 0x61,
+	0x73, 0x74, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x46, 0x0a, 0x0b, 0x47, 0x65, 0x74,
+	0x46, 0x6f, 0x72, 0x65, 0x63, 0x61, 0x73, 0x74, 0x12, 0x19, 0x2e, 0x66, 0x6f, 0x72, 0x65, 0x63,
+	0x61, 0x73, 0x74, 0x2e, 0x46, 0x6f, 0x72, 0x65, 0x63, 0x61, 0x73, 0x74, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x1a, 0x2e, 0x66, 0x6f, 0x72, 0x65, 0x63, 0x61, 0x73, 0x74, 0x2e, 0x46,
+	0x6f, 0x72, 0x65, 0x63, 0x61, 0x73, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22,
+	0x00, 0x12, 0x3a, 0x0a, 0x07, 0x47, 0x65, 0x74, 0x47, 0x72, 0x69, 0x64, 0x12, 0x15, 0x2e, 0x66,
+	0x6f, 0x72, 0x65, 0x63, 0x61, 0x73, 0x74, 0x2e, 0x47, 0x72, 0x69, 0x64, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x66, 0x6f, 0x72, 0x65, 0x63, 0x61, 0x73, 0x74, 0x2e, 0x47,
+	0x72, 0x69, 0x64, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x42, 0x17, 0x5a,
+	0x15, 0x68, 0x73, 0x74, 0x69, 0x6e, 0x2f, 0x7a, 0x65, 0x70, 0x68, 0x79, 0x72, 0x3b, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -322,32 +1226,52 @@ func file_protobuf_rpc_proto_rawDescGZIP() []byte {
 	return file_protobuf_rpc_proto_rawDescData
 }
 
-var file_protobuf_rpc_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_protobuf_rpc_proto_msgTypes = make([]protoimpl.MessageInfo, 15)
 var file_protobuf_rpc_proto_goTypes = []interface{}{
 	(*ForecastResponse)(nil),   // 0: forecast.ForecastResponse
-	(*ForecastRequest)(nil),    // 1: forecast.ForecastRequest
-	nil,                        // 2: forecast.ForecastResponse.UsedModelsEntry
-	nil,                        // 3: forecast.ForecastResponse.DailyEntry
-	nil,                        // 4: forecast.ForecastResponse.HourlyEntry
-	nil,                        // 5: forecast.ForecastResponse.Minutely15Entry
-	(*structpb.ListValue)(nil), // 6: google.protobuf.ListValue
+	(*Condition)(nil),          // 1: forecast.Condition
+	(*Astronomy)(nil),          // 2: forecast.Astronomy
+	(*GridCell)(nil),           // 3: forecast.GridCell
+	(*SolarPosition)(nil),      // 4: forecast.SolarPosition
+	(*Conditions)(nil),         // 5: forecast.Conditions
+	(*ForecastRequest)(nil),    // 6: forecast.ForecastRequest
+	(*PVOptions)(nil),          // 7: forecast.PVOptions
+	(*GridRequest)(nil),        // 8: forecast.GridRequest
+	(*GridResponse)(nil),       // 9: forecast.GridResponse
+	nil,                        // 10: forecast.ForecastResponse.UsedModelsEntry
+	nil,                        // 11: forecast.ForecastResponse.DailyEntry
+	nil,                        // 12: forecast.ForecastResponse.HourlyEntry
+	nil,                        // 13: forecast.ForecastResponse.Minutely15Entry
+	nil,                        // 14: forecast.ForecastResponse.ModelRangesEntry
+	(*structpb.ListValue)(nil), // 15: google.protobuf.ListValue
 }
 var file_protobuf_rpc_proto_depIdxs = []int32{
-	2, // 0: forecast.ForecastResponse.used_models:type_name -> forecast.ForecastResponse.UsedModelsEntry
-	3, // 1: forecast.ForecastResponse.daily:type_name -> forecast.ForecastResponse.DailyEntry
-	4, // 2: forecast.ForecastResponse.hourly:type_name -> forecast.ForecastResponse.HourlyEntry
-	5, // 3: forecast.ForecastResponse.minutely15:type_name -> forecast.ForecastResponse.Minutely15Entry
-	6, // 4: forecast.ForecastResponse.UsedModelsEntry.value:type_name -> google.protobuf.ListValue
-	6, // 5: forecast.ForecastResponse.DailyEntry.value:type_name -> google.protobuf.ListValue
-	6, // 6: forecast.ForecastResponse.HourlyEntry.value:type_name -> google.protobuf.ListValue
-	6, // 7: forecast.ForecastResponse.Minutely15Entry.value:type_name -> google.protobuf.ListValue
-	1, // 8: forecast.ForecastService.GetForecast:input_type -> forecast.ForecastRequest
-	0, // 9: forecast.ForecastService.GetForecast:output_type -> forecast.ForecastResponse
-	9, // [9:10] is the sub-list for method output_type
-	8, // [8:9] is the sub-list for method input_type
-	8, // [8:8] is the sub-list for extension type_name
-	8, // [8:8] is the sub-list for extension extendee
-	0, // [0:8] is the sub-list for field type_name
+	10, // 0: forecast.ForecastResponse.used_models:type_name -> forecast.ForecastResponse.UsedModelsEntry
+	11, // 1: forecast.ForecastResponse.daily:type_name -> forecast.ForecastResponse.DailyEntry
+	12, // 2: forecast.ForecastResponse.hourly:type_name -> forecast.ForecastResponse.HourlyEntry
+	13, // 3: forecast.ForecastResponse.minutely15:type_name -> forecast.ForecastResponse.Minutely15Entry
+	14, // 4: forecast.ForecastResponse.model_ranges:type_name -> forecast.ForecastResponse.ModelRangesEntry
+	5,  // 5: forecast.ForecastResponse.conditions:type_name -> forecast.Conditions
+	2,  // 6: forecast.ForecastResponse.astronomy:type_name -> forecast.Astronomy
+	4,  // 7: forecast.ForecastResponse.solar_position:type_name -> forecast.SolarPosition
+	3,  // 8: forecast.ForecastResponse.grid_cell:type_name -> forecast.GridCell
+	1,  // 9: forecast.Conditions.hourly:type_name -> forecast.Condition
+	1,  // 10: forecast.Conditions.daily:type_name -> forecast.Condition
+	7,  // 11: forecast.ForecastRequest.pv:type_name -> forecast.PVOptions
+	15, // 12: forecast.ForecastResponse.UsedModelsEntry.value:type_name -> google.protobuf.ListValue
+	15, // 13: forecast.ForecastResponse.DailyEntry.value:type_name -> google.protobuf.ListValue
+	15, // 14: forecast.ForecastResponse.HourlyEntry.value:type_name -> google.protobuf.ListValue
+	15, // 15: forecast.ForecastResponse.Minutely15Entry.value:type_name -> google.protobuf.ListValue
+	15, // 16: forecast.ForecastResponse.ModelRangesEntry.value:type_name -> google.protobuf.ListValue
+	6,  // 17: forecast.ForecastService.GetForecast:input_type -> forecast.ForecastRequest
+	8,  // 18: forecast.ForecastService.GetGrid:input_type -> forecast.GridRequest
+	0,  // 19: forecast.ForecastService.GetForecast:output_type -> forecast.ForecastResponse
+	9,  // 20: forecast.ForecastService.GetGrid:output_type -> forecast.GridResponse
+	19, // [19:21] is the sub-list for method output_type
+	17, // [17:19] is the sub-list for method input_type
+	17, // [17:17] is the sub-list for extension type_name
+	17, // [17:17] is the sub-list for extension extendee
+	0,  // [0:17] is the sub-list for field type_name
 }
 
 func init() { file_protobuf_rpc_proto_init() }
@@ -369,6 +1293,66 @@ func file_protobuf_rpc_proto_init() {
 			}
 		}
 		file_protobuf_rpc_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Condition); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_protobuf_rpc_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Astronomy); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_protobuf_rpc_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GridCell); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_protobuf_rpc_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SolarPosition); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_protobuf_rpc_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Conditions); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_protobuf_rpc_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*ForecastRequest); i {
 			case 0:
 				return &v.state
@@ -380,6 +1364,42 @@ func file_protobuf_rpc_proto_init() {
 				return nil
 			}
 		}
+		file_protobuf_rpc_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PVOptions); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_protobuf_rpc_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GridRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_protobuf_rpc_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GridResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -387,7 +1407,7 @@ func file_protobuf_rpc_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_protobuf_rpc_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   6,
+			NumMessages:   15,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -24,6 +24,8 @@ const _ = grpc.SupportPackageIsVersion7
 type ForecastServiceClient interface {
 	// Retrieves weather forecast based on the given request.
 	GetForecast(ctx context.Context, in *ForecastRequest, opts ...grpc.CallOption) (*ForecastResponse, error)
+	// Retrieves the field of a parameter within a bounding box.
+	GetGrid(ctx context.Context, in *GridRequest, opts ...grpc.CallOption) (*GridResponse, error)
 }
 
 type forecastServiceClient struct {
@@ -43,12 +45,23 @@ func (c *forecastServiceClient) GetForecast(ctx context.Context, in *ForecastReq
 	return out, nil
 }
 
+func (c *forecastServiceClient) GetGrid(ctx context.Context, in *GridRequest, opts ...grpc.CallOption) (*GridResponse, error) {
+	out := new(GridResponse)
+	err := c.cc.Invoke(ctx, "/forecast.ForecastService/GetGrid", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ForecastServiceServer is the server API for ForecastService service.
 // All implementations must embed UnimplementedForecastServiceServer
 // for forward compatibility
 type ForecastServiceServer interface {
 	// Retrieves weather forecast based on the given request.
 	GetForecast(context.Context, *ForecastRequest) (*ForecastResponse, error)
+	// Retrieves the field of a parameter within a bounding box.
+	GetGrid(context.Context, *GridRequest) (*GridResponse, error)
 	mustEmbedUnimplementedForecastServiceServer()
 }
 
@@ -59,6 +72,9 @@ type UnimplementedForecastServiceServer struct {
 func (UnimplementedForecastServiceServer) GetForecast(context.Context, *ForecastRequest) (*ForecastResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetForecast not implemented")
 }
+func (UnimplementedForecastServiceServer) GetGrid(context.Context, *GridRequest) (*GridResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetGrid not implemented")
+}
 func (UnimplementedForecastServiceServer) mustEmbedUnimplementedForecastServiceServer() {}
 
 // UnsafeForecastServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -90,6 +106,24 @@ func _ForecastService_GetForecast_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ForecastService_GetGrid_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GridRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ForecastServiceServer).GetGrid(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/forecast.ForecastService/GetGrid",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ForecastServiceServer).GetGrid(ctx, req.(*GridRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // ForecastService_ServiceDesc is the grpc.ServiceDesc for ForecastService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -101,6 +135,10 @@ var ForecastService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetForecast",
 			Handler:    _ForecastService_GetForecast_Handler,
 		},
+		{
+			MethodName: "GetGrid",
+			Handler:    _ForecastService_GetGrid_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "protobuf/rpc.proto",
@@ -0,0 +1,159 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "hstin/zephyr/helper"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/phuslu/log"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const accessInfoLocal = "accessInfo"
+
+type accessInfoContextKey struct{}
+
+// accessInfo collects details of a request for the access log, handlers fill in what they know
+type accessInfo struct {
+	hasLocation bool
+	latitude    float64
+	longitude   float64
+	model       string
+	parameters  int
+	// Name of the API key of the request
+	key string
+}
+
+func (a *accessInfo) setLocation(latitude, longitude float64) {
+	a.hasLocation = true
+	a.latitude = latitude
+	a.longitude = longitude
+}
+
+// traceFields adds the trace id of the request, so the entry can be matched with its trace
+func traceFields(ctx context.Context, e *log.Entry) *log.Entry {
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+		e = e.Str("trace_id", spanContext.TraceID().String())
+	}
+	return e
+}
+
+func (a *accessInfo) fields(e *log.Entry) *log.Entry {
+	if a.hasLocation {
+		e = e.Float64("lat", a.latitude).Float64("lng", a.longitude)
+	}
+	if a.model != "" {
+		e = e.Str("model", a.model)
+	}
+	if a.parameters > 0 {
+		e = e.Int("params", a.parameters)
+	}
+	if a.key != "" {
+		e = e.Str("key", a.key)
+	}
+	return e
+}
+
+// httpAccessInfo returns the access info of the request, details set on requests without
+// access log are discarded
+func httpAccessInfo(c *fiber.Ctx) *accessInfo {
+	if info, ok := c.Locals(accessInfoLocal).(*accessInfo); ok {
+		return info
+	}
+	return &accessInfo{}
+}
+
+func grpcAccessInfo(ctx context.Context) *accessInfo {
+	if info, ok := ctx.Value(accessInfoContextKey{}).(*accessInfo); ok {
+		return info
+	}
+	return &accessInfo{}
+}
+
+// accessLogMiddleware logs every HTTP request after it was answered
+func accessLogMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		info := &accessInfo{}
+		c.Locals(accessInfoLocal, info)
+
+		err := c.Next()
+
+		statusCode := c.Response().StatusCode()
+		if err != nil {
+			var fiberError *fiber.Error
+			if errors.As(err, &fiberError) {
+				statusCode = fiberError.Code
+			} else {
+				statusCode = fiber.StatusInternalServerError
+			}
+		}
+
+		entry := HTTPLog.Info()
+		if statusCode >= fiber.StatusInternalServerError {
+			entry = HTTPLog.Error().Err(err)
+		}
+
+		info.fields(traceFields(c.UserContext(), entry)).
+			Str("method", c.Method()).
+			Str("path", c.Path()).
+			Int("status", statusCode).
+			Dur("latency", time.Since(start)).
+			Str("ip", c.IP()).
+			Int("bytes", len(c.Response().Body())).
+			Msg("request")
+
+		return err
+	}
+}
+
+func logGRPCRequest(ctx context.Context, method string, start time.Time, info *accessInfo, err error) {
+	code := status.Code(err)
+
+	entry := GRPCLog.Info()
+	if code == codes.Internal || code == codes.Unknown {
+		entry = GRPCLog.Error().Err(err)
+	}
+
+	address := ""
+	if p, ok := peer.FromContext(ctx); ok {
+		address = p.Addr.String()
+	}
+
+	info.fields(traceFields(ctx, entry)).
+		Str("method", method).
+		Str("code", code.String()).
+		Dur("latency", time.Since(start)).
+		Str("peer", address).
+		Msg("request")
+}
+
+func accessLogUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		access := &accessInfo{}
+
+		resp, err := handler(context.WithValue(ctx, accessInfoContextKey{}, access), req)
+
+		logGRPCRequest(ctx, info.FullMethod, start, access, err)
+		return resp, err
+	}
+}
+
+func accessLogStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+
+		err := handler(srv, ss)
+
+		logGRPCRequest(ss.Context(), info.FullMethod, start, &accessInfo{}, err)
+		return err
+	}
+}
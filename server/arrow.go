@@ -0,0 +1,243 @@
+package server
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"time"
+
+	flatbuffers "github.com/google/flatbuffers/go"
+)
+
+// Arrow IPC streaming format, written without the Arrow library: a schema message, one record
+// batch with all steps and the end-of-stream marker. The flatbuffer slots and enum values follow
+// Schema.fbs and Message.fbs of the Arrow columnar format, version 5.
+// See https://arrow.apache.org/docs/format/Columnar.html#ipc-streaming-format
+const (
+	arrowMetadataV5 = 4
+
+	arrowHeaderSchema      = 1
+	arrowHeaderRecordBatch = 3
+
+	arrowTypeFloatingPoint = 3
+	arrowTypeDate          = 8
+	arrowTypeTimestamp     = 10
+
+	arrowPrecisionDouble     = 2
+	arrowDateUnitDay         = 0
+	arrowDateUnitMillisecond = 1
+	arrowTimeUnitMillisecond = 1
+)
+
+// Every message starts with the continuation marker and the length of its metadata
+const arrowContinuation = 0xFFFFFFFF
+
+// arrowColumn holds a fixed-width column of the record batch
+type arrowColumn struct {
+	name     string
+	nullable bool
+	typeID   byte
+	// Adds the type table of the column to the builder
+	fieldType func(b *flatbuffers.Builder) flatbuffers.UOffsetT
+	// Validity bitmap, empty if the column has no nulls
+	validity  []byte
+	nullCount int
+	values    []byte
+}
+
+// writeArrow writes the series as Arrow IPC stream with the same columns as writeCSV. The time
+// column is a timestamp in milliseconds with the timezone of the location, daily series use a
+// date32 column. Missing and NaN values are null.
+func writeArrow(w io.Writer, series map[string][]float64, startTime time.Time, stepLength time.Duration, daily bool) error {
+	names, steps := seriesColumns(series)
+	columns := make([]arrowColumn, 0, len(names)+1)
+
+	if daily {
+		values := make([]byte, 4*steps)
+		for j := 0; j < steps; j++ {
+			days := stepTime(startTime, stepLength, j).Unix() / 86400
+			binary.LittleEndian.PutUint32(values[4*j:], uint32(int32(days)))
+		}
+
+		columns = append(columns, arrowColumn{
+			name:   "time",
+			typeID: arrowTypeDate,
+			fieldType: func(b *flatbuffers.Builder) flatbuffers.UOffsetT {
+				b.StartObject(1)
+				b.PrependInt16Slot(0, arrowDateUnitDay, arrowDateUnitMillisecond)
+				return b.EndObject()
+			},
+			values: values,
+		})
+	} else {
+		values := make([]byte, 8*steps)
+		for j := 0; j < steps; j++ {
+			binary.LittleEndian.PutUint64(values[8*j:], uint64(stepTime(startTime, stepLength, j).UnixMilli()))
+		}
+
+		timezone := startTime.Location().String()
+
+		columns = append(columns, arrowColumn{
+			name:   "time",
+			typeID: arrowTypeTimestamp,
+			fieldType: func(b *flatbuffers.Builder) flatbuffers.UOffsetT {
+				tz := b.CreateString(timezone)
+				b.StartObject(2)
+				b.PrependUOffsetTSlot(1, tz, 0)
+				b.PrependInt16Slot(0, arrowTimeUnitMillisecond, 0)
+				return b.EndObject()
+			},
+			values: values,
+		})
+	}
+
+	for _, name := range names {
+		column := arrowColumn{
+			name:     name,
+			nullable: true,
+			typeID:   arrowTypeFloatingPoint,
+			fieldType: func(b *flatbuffers.Builder) flatbuffers.UOffsetT {
+				b.StartObject(1)
+				b.PrependInt16Slot(0, arrowPrecisionDouble, 0)
+				return b.EndObject()
+			},
+			validity: make([]byte, (steps+7)/8),
+			values:   make([]byte, 8*steps),
+		}
+
+		for j := 0; j < steps; j++ {
+			if j >= len(series[name]) || math.IsNaN(series[name][j]) {
+				column.nullCount++
+				continue
+			}
+
+			column.validity[j/8] |= 1 << (j % 8)
+			binary.LittleEndian.PutUint64(column.values[8*j:], math.Float64bits(series[name][j]))
+		}
+
+		if column.nullCount == 0 {
+			column.validity = nil
+		}
+
+		columns = append(columns, column)
+	}
+
+	if err := writeArrowSchema(w, columns); err != nil {
+		return err
+	}
+
+	if err := writeArrowRecordBatch(w, columns, steps); err != nil {
+		return err
+	}
+
+	// End of stream
+	var end [8]byte
+	binary.LittleEndian.PutUint32(end[:], arrowContinuation)
+	_, err := w.Write(end[:])
+	return err
+}
+
+func writeArrowSchema(w io.Writer, columns []arrowColumn) error {
+	b := flatbuffers.NewBuilder(1024)
+
+	fields := make([]flatbuffers.UOffsetT, len(columns))
+	for i, column := range columns {
+		name := b.CreateString(column.name)
+		fieldType := column.fieldType(b)
+
+		// Readers expect the children of every field, even if there are none
+		b.StartVector(4, 0, 4)
+		children := b.EndVector(0)
+
+		b.StartObject(7)
+		b.PrependUOffsetTSlot(5, children, 0)
+		b.PrependUOffsetTSlot(3, fieldType, 0)
+		b.PrependByteSlot(2, column.typeID, 0)
+		b.PrependBoolSlot(1, column.nullable, false)
+		b.PrependUOffsetTSlot(0, name, 0)
+		fields[i] = b.EndObject()
+	}
+
+	b.StartVector(4, len(fields), 4)
+	for i := len(fields) - 1; i >= 0; i-- {
+		b.PrependUOffsetT(fields[i])
+	}
+	fieldVector := b.EndVector(len(fields))
+
+	b.StartObject(4)
+	b.PrependUOffsetTSlot(1, fieldVector, 0)
+	schema := b.EndObject()
+
+	return writeArrowMessage(w, b, arrowHeaderSchema, schema, nil)
+}
+
+func writeArrowRecordBatch(w io.Writer, columns []arrowColumn, length int) error {
+	b := flatbuffers.NewBuilder(1024)
+
+	// Buffers are stored one after another in the body, each padded to 8 bytes
+	var body []byte
+	type buffer struct{ offset, length int64 }
+	buffers := make([]buffer, 0, 2*len(columns))
+
+	for _, column := range columns {
+		for _, data := range [][]byte{column.validity, column.values} {
+			buffers = append(buffers, buffer{offset: int64(len(body)), length: int64(len(data))})
+			body = append(body, data...)
+			body = append(body, make([]byte, padding8(len(data)))...)
+		}
+	}
+
+	b.StartVector(16, len(columns), 8)
+	for i := len(columns) - 1; i >= 0; i-- {
+		b.Prep(8, 16)
+		b.PrependInt64(int64(columns[i].nullCount))
+		b.PrependInt64(int64(length))
+	}
+	nodes := b.EndVector(len(columns))
+
+	b.StartVector(16, len(buffers), 8)
+	for i := len(buffers) - 1; i >= 0; i-- {
+		b.Prep(8, 16)
+		b.PrependInt64(buffers[i].length)
+		b.PrependInt64(buffers[i].offset)
+	}
+	bufferVector := b.EndVector(len(buffers))
+
+	b.StartObject(5)
+	b.PrependUOffsetTSlot(2, bufferVector, 0)
+	b.PrependUOffsetTSlot(1, nodes, 0)
+	b.PrependInt64Slot(0, int64(length), 0)
+	recordBatch := b.EndObject()
+
+	return writeArrowMessage(w, b, arrowHeaderRecordBatch, recordBatch, body)
+}
+
+// writeArrowMessage finishes the message with the given header and writes it followed by the body
+func writeArrowMessage(w io.Writer, b *flatbuffers.Builder, headerType byte, header flatbuffers.UOffsetT, body []byte) error {
+	b.StartObject(5)
+	b.PrependInt64Slot(3, int64(len(body)), 0)
+	b.PrependUOffsetTSlot(2, header, 0)
+	b.PrependByteSlot(1, headerType, 0)
+	b.PrependInt16Slot(0, arrowMetadataV5, 0)
+	b.Finish(b.EndObject())
+
+	metadata := b.FinishedBytes()
+	padding := padding8(len(metadata))
+
+	// The body has to start at a multiple of 8 bytes
+	var prefix [8]byte
+	binary.LittleEndian.PutUint32(prefix[:4], arrowContinuation)
+	binary.LittleEndian.PutUint32(prefix[4:], uint32(len(metadata)+padding))
+
+	for _, data := range [][]byte{prefix[:], metadata, make([]byte, padding), body} {
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func padding8(length int) int {
+	return (8 - length%8) % 8
+}
@@ -0,0 +1,171 @@
+package server
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow/go/arrow"
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/apache/arrow/go/arrow/ipc"
+)
+
+// readArrow decodes the stream with the Arrow library and returns the schema and the single
+// record batch
+func readArrow(t *testing.T, data []byte) (*arrow.Schema, array.Record) {
+	t.Helper()
+
+	reader, err := ipc.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Release()
+
+	if !reader.Next() {
+		t.Fatalf("no record batch: %v", reader.Err())
+	}
+
+	record := reader.Record()
+	record.Retain()
+
+	if reader.Next() {
+		t.Error("got more than one record batch")
+	}
+	if err := reader.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	return reader.Schema(), record
+}
+
+// checkFloat64Column compares a column with the values, NaN and missing values have to be null
+func checkFloat64Column(t *testing.T, column array.Interface, values []float64, steps int) {
+	t.Helper()
+
+	floats, ok := column.(*array.Float64)
+	if !ok {
+		t.Fatalf("got column of type %s, want float64", column.DataType())
+	}
+	if floats.Len() != steps {
+		t.Fatalf("got %d values, want %d", floats.Len(), steps)
+	}
+
+	nulls := 0
+	for j := 0; j < steps; j++ {
+		if j >= len(values) || math.IsNaN(values[j]) {
+			nulls++
+			if !floats.IsNull(j) {
+				t.Errorf("step %d: got %v, want null", j, floats.Value(j))
+			}
+			continue
+		}
+
+		if floats.IsNull(j) {
+			t.Errorf("step %d: got null, want %v", j, values[j])
+		} else if floats.Value(j) != values[j] {
+			t.Errorf("step %d: got %v, want %v", j, floats.Value(j), values[j])
+		}
+	}
+
+	if floats.NullN() != nulls {
+		t.Errorf("got %d nulls, want %d", floats.NullN(), nulls)
+	}
+}
+
+func TestWriteArrowHourly(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	startTime := time.Date(2024, 5, 1, 14, 30, 0, 0, loc)
+	series := map[string][]float64{
+		"temperature":   {12.5, 13, math.NaN(), 11.25, 10, 9.5, 9, 8.75, 8.5, 8},
+		"precipitation": {0, 0.2, 0.4},
+		"wind_speed":    {1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+	}
+	const steps = 10
+
+	var buffer bytes.Buffer
+	if err := writeArrow(&buffer, series, startTime, time.Hour, false); err != nil {
+		t.Fatal(err)
+	}
+
+	schema, record := readArrow(t, buffer.Bytes())
+	defer record.Release()
+
+	wantFields := []arrow.Field{
+		{Name: "time", Type: &arrow.TimestampType{Unit: arrow.Millisecond, TimeZone: "Europe/Berlin"}},
+		{Name: "precipitation", Type: arrow.PrimitiveTypes.Float64, Nullable: true},
+		{Name: "temperature", Type: arrow.PrimitiveTypes.Float64, Nullable: true},
+		{Name: "wind_speed", Type: arrow.PrimitiveTypes.Float64, Nullable: true},
+	}
+
+	if len(schema.Fields()) != len(wantFields) {
+		t.Fatalf("got %d fields, want %d", len(schema.Fields()), len(wantFields))
+	}
+	for i, field := range schema.Fields() {
+		want := wantFields[i]
+		if field.Name != want.Name || field.Nullable != want.Nullable || !arrow.TypeEqual(field.Type, want.Type) {
+			t.Errorf("field %d: got %s %s nullable=%t, want %s %s nullable=%t", i, field.Name, field.Type, field.Nullable, want.Name, want.Type, want.Nullable)
+		}
+	}
+
+	if record.NumRows() != steps {
+		t.Fatalf("got %d rows, want %d", record.NumRows(), steps)
+	}
+
+	times, ok := record.Column(0).(*array.Timestamp)
+	if !ok {
+		t.Fatalf("got time column of type %s", record.Column(0).DataType())
+	}
+	if times.NullN() != 0 {
+		t.Errorf("got %d null timestamps", times.NullN())
+	}
+	for j := 0; j < steps; j++ {
+		want := time.Date(2024, 5, 1, j, 0, 0, 0, time.UTC).UnixMilli()
+		if int64(times.Value(j)) != want {
+			t.Errorf("step %d: got timestamp %d, want %d", j, times.Value(j), want)
+		}
+	}
+
+	checkFloat64Column(t, record.Column(1), series["precipitation"], steps)
+	checkFloat64Column(t, record.Column(2), series["temperature"], steps)
+	checkFloat64Column(t, record.Column(3), series["wind_speed"], steps)
+}
+
+func TestWriteArrowDaily(t *testing.T) {
+	startTime := time.Date(2024, 5, 1, 14, 30, 0, 0, time.UTC)
+	series := map[string][]float64{
+		"temperature_max": {20, 21.5, 19},
+		"temperature_min": {math.NaN(), 8, 7.5},
+	}
+	const steps = 3
+
+	var buffer bytes.Buffer
+	if err := writeArrow(&buffer, series, startTime, 24*time.Hour, true); err != nil {
+		t.Fatal(err)
+	}
+
+	schema, record := readArrow(t, buffer.Bytes())
+	defer record.Release()
+
+	if field := schema.Field(0); field.Name != "time" || !arrow.TypeEqual(field.Type, arrow.FixedWidthTypes.Date32) {
+		t.Errorf("got time field %s %s, want date32", field.Name, field.Type)
+	}
+
+	dates, ok := record.Column(0).(*array.Date32)
+	if !ok {
+		t.Fatalf("got time column of type %s", record.Column(0).DataType())
+	}
+	for j := 0; j < steps; j++ {
+		want := time.Date(2024, 5, 1+j, 0, 0, 0, 0, time.UTC).Unix() / 86400
+		if int64(dates.Value(j)) != want {
+			t.Errorf("day %d: got date %d, want %d", j, dates.Value(j), want)
+		}
+	}
+
+	checkFloat64Column(t, record.Column(1), series["temperature_max"], steps)
+	checkFloat64Column(t, record.Column(2), series["temperature_min"], steps)
+}
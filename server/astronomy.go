@@ -0,0 +1,110 @@
+package server
+
+import (
+	"hstin/zephyr/astro"
+	"math"
+	"time"
+)
+
+// Astronomy holds one entry per day of the daily series. Times are unix timestamps in
+// milliseconds, null if the event does not occur on that day (polar day or night).
+type Astronomy struct {
+	Sunrise               []*int64 `json:"sunrise"`
+	Sunset                []*int64 `json:"sunset"`
+	CivilTwilightBegin    []*int64 `json:"civil_twilight_begin"`
+	CivilTwilightEnd      []*int64 `json:"civil_twilight_end"`
+	NauticalTwilightBegin []*int64 `json:"nautical_twilight_begin"`
+	NauticalTwilightEnd   []*int64 `json:"nautical_twilight_end"`
+	// Seconds between sunrise and sunset
+	DaylightDuration []float64 `json:"daylight_duration"`
+	// 0 new moon, 0.25 first quarter, 0.5 full moon, 0.75 last quarter, at local noon
+	MoonPhase []float64 `json:"moon_phase"`
+	// Illuminated fraction of the moon at local noon
+	MoonIllumination []float64 `json:"moon_illumination"`
+}
+
+// SolarPosition holds the position of the sun in degrees for every step of the hourly series
+type SolarPosition struct {
+	Elevation []float64 `json:"elevation"`
+	Azimuth   []float64 `json:"azimuth"`
+}
+
+// buildAstronomy calculates the astronomy of every day of the daily series. The days are the
+// calendar days of the daily series, the events are calculated for these days in the local timezone.
+func buildAstronomy(startTime time.Time, forecastDays int, latitude, longitude float64) *Astronomy {
+	days := forecastDays + 1
+
+	astronomy := &Astronomy{
+		Sunrise:               make([]*int64, days),
+		Sunset:                make([]*int64, days),
+		CivilTwilightBegin:    make([]*int64, days),
+		CivilTwilightEnd:      make([]*int64, days),
+		NauticalTwilightBegin: make([]*int64, days),
+		NauticalTwilightEnd:   make([]*int64, days),
+		DaylightDuration:      make([]float64, days),
+		MoonPhase:             make([]float64, days),
+		MoonIllumination:      make([]float64, days),
+	}
+
+	start := startTime.UTC().Truncate(24 * time.Hour)
+
+	for day := 0; day < days; day++ {
+		date := start.AddDate(0, 0, day)
+		localNoon := time.Date(date.Year(), date.Month(), date.Day(), 12, 0, 0, 0, startTime.Location())
+
+		sun := astro.SunEventsAt(localNoon, latitude, longitude, astro.SunriseElevation)
+		civil := astro.SunEventsAt(localNoon, latitude, longitude, astro.CivilTwilightElevation)
+		nautical := astro.SunEventsAt(localNoon, latitude, longitude, astro.NauticalTwilightElevation)
+
+		astronomy.Sunrise[day] = timestamp(sun.Rise)
+		astronomy.Sunset[day] = timestamp(sun.Set)
+		astronomy.CivilTwilightBegin[day] = timestamp(civil.Rise)
+		astronomy.CivilTwilightEnd[day] = timestamp(civil.Set)
+		astronomy.NauticalTwilightBegin[day] = timestamp(nautical.Rise)
+		astronomy.NauticalTwilightEnd[day] = timestamp(nautical.Set)
+		astronomy.DaylightDuration[day] = sun.Duration().Seconds()
+
+		phase, illumination := astro.MoonPhase(localNoon)
+		astronomy.MoonPhase[day] = math.Round(phase*1000) / 1000
+		astronomy.MoonIllumination[day] = math.Round(illumination*1000) / 1000
+	}
+
+	return astronomy
+}
+
+// buildSolarPosition calculates the position of the sun for every step of the hourly series
+func buildSolarPosition(startTime time.Time, forecastDays int, hourly map[string][]float64, latitude, longitude float64) *SolarPosition {
+	steps := 24 * (forecastDays + 1)
+	for _, values := range hourly {
+		if len(values) > 0 {
+			steps = len(values)
+			break
+		}
+	}
+
+	position := &SolarPosition{
+		Elevation: make([]float64, steps),
+		Azimuth:   make([]float64, steps),
+	}
+
+	start := startTime.Truncate(24 * time.Hour)
+	stepLength := time.Duration(forecastDays+1) * 24 * time.Hour / time.Duration(steps)
+
+	for j := 0; j < steps; j++ {
+		elevation, azimuth := astro.SolarPosition(start.Add(time.Duration(j)*stepLength), latitude, longitude)
+
+		position.Elevation[j] = math.Round(elevation*100) / 100
+		position.Azimuth[j] = math.Round(azimuth*100) / 100
+	}
+
+	return position
+}
+
+func timestamp(t time.Time) *int64 {
+	if t.IsZero() {
+		return nil
+	}
+
+	ms := t.UnixMilli()
+	return &ms
+}
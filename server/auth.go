@@ -0,0 +1,144 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"hstin/zephyr/auth"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const apiKeyHeader = "X-API-Key"
+
+// bearerToken returns the token of an "Authorization: Bearer <token>" value
+func bearerToken(authorization string) string {
+	if len(authorization) > 7 && strings.EqualFold(authorization[:7], "bearer ") {
+		return strings.TrimSpace(authorization[7:])
+	}
+	return ""
+}
+
+// httpAPIKey reads the key from the X-API-Key header, a bearer token or the api_key query parameter
+func httpAPIKey(c *fiber.Ctx) string {
+	if key := c.Get(apiKeyHeader); key != "" {
+		return key
+	}
+
+	if key := bearerToken(c.Get(fiber.HeaderAuthorization)); key != "" {
+		return key
+	}
+
+	return c.Query("api_key")
+}
+
+// authMiddleware rejects requests without a valid API key and enforces the limits of the key.
+// GET /usage checks the key itself and does not count against the limits.
+func authMiddleware(store *auth.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if c.Path() == "/usage" {
+			return c.Next()
+		}
+
+		usage, err := store.Authorize(httpAPIKey(c))
+		httpAccessInfo(c).key = usage.Name
+
+		if usage.DailyQuota > 0 {
+			c.Set("X-Quota-Limit", strconv.FormatInt(usage.DailyQuota, 10))
+			c.Set("X-Quota-Remaining", strconv.FormatInt(usage.RemainingToday, 10))
+		}
+
+		switch {
+		case errors.Is(err, auth.ErrMissingKey), errors.Is(err, auth.ErrUnknownKey):
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, auth.ErrRateLimited):
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(usage.RetryAfter.Seconds()))))
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, auth.ErrQuotaExceeded):
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{"error": err.Error()})
+		case err != nil:
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Error checking API key"})
+		}
+
+		return c.Next()
+	}
+}
+
+// handleUsage answers GET /usage with the counters of the API key of the request
+func handleUsage(store *auth.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := httpAPIKey(c)
+		if key == "" {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": auth.ErrMissingKey.Error()})
+		}
+
+		usage, ok := store.Usage(key)
+		if !ok {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": auth.ErrUnknownKey.Error()})
+		}
+
+		httpAccessInfo(c).key = usage.Name
+		return c.JSON(usage)
+	}
+}
+
+// grpcAPIKey reads the key from the x-api-key or authorization metadata
+func grpcAPIKey(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	if values := md.Get(strings.ToLower(apiKeyHeader)); len(values) > 0 && values[0] != "" {
+		return values[0]
+	}
+
+	if values := md.Get("authorization"); len(values) > 0 {
+		return bearerToken(values[0])
+	}
+
+	return ""
+}
+
+func authorizeGRPC(store *auth.Store, ctx context.Context) error {
+	usage, err := store.Authorize(grpcAPIKey(ctx))
+	grpcAccessInfo(ctx).key = usage.Name
+
+	switch {
+	case errors.Is(err, auth.ErrMissingKey), errors.Is(err, auth.ErrUnknownKey):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, auth.ErrRateLimited):
+		_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(int(math.Ceil(usage.RetryAfter.Seconds())))))
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, auth.ErrQuotaExceeded):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case err != nil:
+		return status.Error(codes.Internal, "error checking API key")
+	}
+
+	return nil
+}
+
+func authUnaryInterceptor(store *auth.Store) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := authorizeGRPC(store, ctx); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func authStreamInterceptor(store *auth.Store) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := authorizeGRPC(store, ss.Context()); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
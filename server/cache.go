@@ -0,0 +1,186 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"hstin/zephyr/cache"
+	"hstin/zephyr/common"
+	"hstin/zephyr/models/base"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Bounds of the Cache-Control max-age, responses are cached until the next run is expected
+const (
+	minCacheMaxAge = time.Minute
+	maxCacheMaxAge = 6 * time.Hour
+)
+
+// Larger results, e.g. long forecasts with many parameters, are not cached. Counted in values,
+// 128k values are 1 MiB.
+const maxCachedValues = 128 * 1024
+
+// Values read from the models are shared by all requests in the same grid cells, they are only
+// used while the data version of their model is unchanged
+var valuesCache = cache.NewLRU[*cachedValues](16384, maxCacheMaxAge)
+
+// cachedValues holds the values of the models for a set of grid cells. The forecast itself, e.g.
+// astronomy, conditions and the echoed point, is calculated for the requested coordinates.
+type cachedValues struct {
+	version     base.DataVersion
+	daily       map[string][]float64
+	hourly      map[string][]float64
+	usedModels  map[string][]string
+	modelRanges map[string][]base.ModelRange
+}
+
+// forecastCells returns the grid cells the values of every model in the chain are read from,
+// the first cell is the one of the model itself. Models without data for the point are left out.
+func forecastCells(ctx context.Context, model common.BaseModel, params []common.ParameterOptions, startTime time.Time, latitude, longitude float64) []base.GridCell {
+	if len(params) == 0 {
+		return nil
+	}
+
+	daysSinceEpoch := common.CalculateDaysSinceEpoch(startTime)
+
+	var cells []base.GridCell
+	for _, m := range base.GetModelChain(model) {
+		cell, err := base.GetGridCell(ctx, m, params[0], daysSinceEpoch, latitude, longitude)
+		if err != nil {
+			continue
+		}
+
+		// Models without a file for the day return the cell of the parent supplying the data
+		if len(cells) > 0 && cells[len(cells)-1] == cell {
+			continue
+		}
+
+		cells = append(cells, cell)
+	}
+
+	return cells
+}
+
+// valuesCacheKey identifies the values of a request by the grid cells, the parameters, the first
+// day and the options that change the values read from the models
+func valuesCacheKey(cells []base.GridCell, params []common.ParameterOptions, startTime time.Time, forecastDays int, blend bool, blendOverlap int) string {
+	var key strings.Builder
+
+	for _, cell := range cells {
+		fmt.Fprintf(&key, "%s:%g,%g;", cell.Model, cell.Latitude, cell.Longitude)
+	}
+
+	names := make([]string, 0, len(params))
+	for _, p := range params {
+		names = append(names, p.DisplayName)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintf(&key, "|%s|%d|%d|%t|%d", strings.Join(names, ","), common.CalculateDaysSinceEpoch(startTime), forecastDays, blend, blendOverlap)
+
+	return key.String()
+}
+
+// newCachedValues keeps the values of a request, nil if they are too large to be cached
+func newCachedValues(version base.DataVersion, daily, hourly map[string][]float64, usedModels map[string][]string, modelRanges map[string][]base.ModelRange) *cachedValues {
+	size := 0
+	for _, series := range []map[string][]float64{daily, hourly} {
+		for _, values := range series {
+			size += len(values)
+		}
+	}
+
+	if size > maxCachedValues {
+		return nil
+	}
+
+	return &cachedValues{
+		version:     version,
+		daily:       copySeries(daily),
+		hourly:      copySeries(hourly),
+		usedModels:  usedModels,
+		modelRanges: modelRanges,
+	}
+}
+
+// get returns copies of the values, the conditions and the PV estimate modify the series
+func (v *cachedValues) get() (daily, hourly map[string][]float64, usedModels map[string][]string, modelRanges map[string][]base.ModelRange) {
+	return copySeries(v.daily), copySeries(v.hourly), v.usedModels, v.modelRanges
+}
+
+func copySeries(series map[string][]float64) map[string][]float64 {
+	result := make(map[string][]float64, len(series))
+	for name, values := range series {
+		result[name] = append([]float64(nil), values...)
+	}
+
+	return result
+}
+
+// forecastETag identifies the response of a forecast request by the data version, the response
+// format, the day and all query parameters including the coordinates
+func forecastETag(c *fiber.Ctx, format string, version base.DataVersion, day time.Time) string {
+	var query []string
+
+	c.Context().QueryArgs().VisitAll(func(key, value []byte) {
+		switch string(key) {
+		case "api_key", "format":
+			return
+		}
+		query = append(query, string(key)+"="+string(value))
+	})
+	sort.Strings(query)
+
+	hash := fnv.New64a()
+	fmt.Fprintf(hash, "%s|%s|%s", format, day.UTC().Format(time.DateOnly), strings.Join(query, "&"))
+
+	return fmt.Sprintf("W/\"%s-%016x\"", version.ID, hash.Sum64())
+}
+
+// setCacheHeaders sets the ETag, Last-Modified and Cache-Control headers of a forecast derived
+// from the ingested runs. Responses are marked private if requests need an API key, so shared
+// caches don't serve them.
+func setCacheHeaders(c *fiber.Ctx, etag string, version base.DataVersion, private bool) {
+	maxAge := minCacheMaxAge
+	if !version.NextRun.IsZero() {
+		maxAge = min(max(time.Until(version.NextRun), minCacheMaxAge), maxCacheMaxAge)
+	}
+
+	visibility := "public"
+	if private {
+		visibility = "private"
+	}
+
+	c.Set(fiber.HeaderETag, etag)
+	c.Set(fiber.HeaderCacheControl, fmt.Sprintf("%s, max-age=%d", visibility, int(maxAge.Seconds())))
+	c.Set(fiber.HeaderVary, fiber.HeaderAccept)
+	if !version.LastModified.IsZero() {
+		c.Set(fiber.HeaderLastModified, version.LastModified.UTC().Format(http.TimeFormat))
+	}
+}
+
+// notModified evaluates the conditional headers of the request, If-None-Match takes precedence
+// over If-Modified-Since. ETags are compared weakly.
+func notModified(c *fiber.Ctx, etag string, lastModified time.Time) bool {
+	if noneMatch := c.Get(fiber.HeaderIfNoneMatch); noneMatch != "" {
+		for _, tag := range strings.Split(noneMatch, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	if modifiedSince := c.Get(fiber.HeaderIfModifiedSince); modifiedSince != "" && !lastModified.IsZero() {
+		t, err := http.ParseTime(modifiedSince)
+		return err == nil && !lastModified.Truncate(time.Second).After(t)
+	}
+
+	return false
+}
@@ -0,0 +1,111 @@
+package server
+
+import (
+	"errors"
+	"hstin/zephyr/astro"
+	"hstin/zephyr/common"
+	"hstin/zephyr/models/base"
+	"time"
+)
+
+const (
+	conditionParameter = "condition"
+
+	// Raw WMO codes in the hourly and daily values (default)
+	conditionFormatCode = "code"
+	// Condition objects with text and icon instead of the raw codes
+	conditionFormatObject = "object"
+)
+
+type Conditions struct {
+	// One entry per hourly step, nil if no model has a value for the step
+	Hourly []*common.Condition `json:"hourly"`
+	// Dominant condition of each day
+	Daily []*common.Condition `json:"daily"`
+}
+
+// parseConditionOptions validates the condition format and language of a request
+// and returns whether condition objects are requested
+func parseConditionOptions(format, language string) (bool, error) {
+	switch format {
+	case "", conditionFormatCode:
+		return false, nil
+	case conditionFormatObject:
+	default:
+		return false, errors.New("invalid condition format")
+	}
+
+	if language != "" && !common.IsConditionLanguage(language) {
+		return false, errors.New("invalid language")
+	}
+
+	return true, nil
+}
+
+// buildConditions converts the condition codes into condition objects and removes the raw codes
+// from the hourly and daily values. Hourly icons use the day or night variant depending on the
+// position of the sun, daily conditions always use the day variant.
+func buildConditions(daily, hourly map[string][]float64, modelRanges map[string][]base.ModelRange, startTime time.Time, forecastDays int, latitude, longitude float64, language string) *Conditions {
+	codes, ok := hourly[conditionParameter]
+
+	delete(hourly, conditionParameter)
+	delete(daily, conditionParameter+"_min")
+	delete(daily, conditionParameter+"_max")
+
+	conditions := &Conditions{
+		Hourly: []*common.Condition{},
+		Daily:  make([]*common.Condition, forecastDays+1),
+	}
+
+	if !ok || len(codes) == 0 {
+		return conditions
+	}
+
+	if language == "" {
+		language = common.ConditionLanguages[0]
+	}
+
+	startTs := startTime.Truncate(24 * time.Hour).UnixMilli()
+	stepLength := int64(forecastDays+1) * 86400 * 1000 / int64(len(codes))
+	stepsPerDay := len(codes) / (forecastDays + 1)
+	ranges := modelRanges[conditionParameter]
+
+	conditions.Hourly = make([]*common.Condition, len(codes))
+	dayCodes := make([]int, 0, stepsPerDay)
+
+	for day := 0; day <= forecastDays; day++ {
+		dayCodes = dayCodes[:0]
+
+		for j := day * stepsPerDay; j < (day+1)*stepsPerDay; j++ {
+			timestamp := startTs + int64(j)*stepLength
+			if !hasValue(ranges, timestamp) {
+				continue
+			}
+
+			code := int(codes[j])
+			isDay := astro.IsDay(time.UnixMilli(timestamp), latitude, longitude)
+
+			condition := common.DecodeCondition(code, isDay, language)
+			conditions.Hourly[j] = &condition
+
+			dayCodes = append(dayCodes, code)
+		}
+
+		if dominant := common.DominantCondition(dayCodes); dominant != -1 {
+			condition := common.DecodeCondition(dominant, true, language)
+			conditions.Daily[day] = &condition
+		}
+	}
+
+	return conditions
+}
+
+// hasValue reports whether one of the model ranges contains the timestamp
+func hasValue(ranges []base.ModelRange, timestamp int64) bool {
+	for _, r := range ranges {
+		if timestamp >= r.From && timestamp <= r.To {
+			return true
+		}
+	}
+	return false
+}
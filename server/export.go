@@ -0,0 +1,149 @@
+package server
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	formatJSON  = "json"
+	formatCSV   = "csv"
+	formatArrow = "arrow"
+
+	mimeCSV   = "text/csv"
+	mimeArrow = "application/vnd.apache.arrow.stream"
+)
+
+var errUnsupportedFormat = errors.New("unsupported format, supported formats are json, csv and arrow")
+
+// negotiateFormat returns the response format of a request. The format query parameter takes
+// precedence over the Accept header, requests without either are answered with JSON.
+// Parquet is not supported, no Parquet writer is available to the build.
+func negotiateFormat(c *fiber.Ctx) (string, error) {
+	switch format := c.Query("format"); format {
+	case formatJSON, formatCSV, formatArrow:
+		return format, nil
+	case "":
+	default:
+		return "", fmt.Errorf("format '%s' is not supported, supported formats are json, csv and arrow", format)
+	}
+
+	switch c.Accepts(fiber.MIMEApplicationJSON, mimeCSV, mimeArrow) {
+	case fiber.MIMEApplicationJSON:
+		return formatJSON, nil
+	case mimeCSV:
+		return formatCSV, nil
+	case mimeArrow:
+		return formatArrow, nil
+	}
+
+	return "", errUnsupportedFormat
+}
+
+// seriesColumns returns the parameter names of the series in alphabetical order and the number of steps
+func seriesColumns(series map[string][]float64) ([]string, int) {
+	columns := make([]string, 0, len(series))
+	steps := 0
+
+	for name, values := range series {
+		columns = append(columns, name)
+		if len(values) > steps {
+			steps = len(values)
+		}
+	}
+	sort.Strings(columns)
+
+	return columns, steps
+}
+
+// stepTime returns the time of step j, the series start at midnight UTC of the day of startTime
+func stepTime(startTime time.Time, stepLength time.Duration, j int) time.Time {
+	return startTime.Truncate(24 * time.Hour).Add(time.Duration(j) * stepLength)
+}
+
+// writeCSV writes one row per step with the ISO 8601 timestamp of the step in the local timezone
+// followed by one column per parameter in alphabetical order. Daily series use the date instead.
+func writeCSV(w io.Writer, series map[string][]float64, startTime time.Time, stepLength time.Duration, daily bool) error {
+	columns, steps := seriesColumns(series)
+
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write(append([]string{"time"}, columns...)); err != nil {
+		return err
+	}
+
+	row := make([]string, len(columns)+1)
+
+	for j := 0; j < steps; j++ {
+		timestamp := stepTime(startTime, stepLength, j)
+
+		if daily {
+			row[0] = timestamp.UTC().Format(time.DateOnly)
+		} else {
+			row[0] = timestamp.In(startTime.Location()).Format(time.RFC3339)
+		}
+
+		for i, name := range columns {
+			row[i+1] = ""
+			if j < len(series[name]) {
+				row[i+1] = strconv.FormatFloat(series[name][j], 'f', -1, 64)
+			}
+		}
+
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// sendSeries answers the request with the series selected by the series query parameter
+// ("hourly" (default), "daily" or "minutely15") in CSV or Arrow format
+func sendSeries(c *fiber.Ctx, format string, response ForecastResponse, startTime time.Time, forecastDays int) error {
+	var series map[string][]float64
+	var stepLength time.Duration
+
+	name := c.Query("series", "hourly")
+
+	switch name {
+	case "hourly":
+		series = response.Hourly
+		stepLength = time.Hour
+
+		for _, values := range series {
+			if len(values) > 0 {
+				stepLength = time.Duration(forecastDays+1) * 24 * time.Hour / time.Duration(len(values))
+				break
+			}
+		}
+	case "daily":
+		series = response.Daily
+		stepLength = 24 * time.Hour
+	case "minutely15":
+		series = response.Minitely15
+		stepLength = 15 * time.Minute
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid series"})
+	}
+
+	if format == formatArrow {
+		c.Set(fiber.HeaderContentType, mimeArrow)
+		c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=\"forecast_%s.arrows\"", name))
+
+		return writeArrow(c.Response().BodyWriter(), series, startTime, stepLength, name == "daily")
+	}
+
+	c.Set(fiber.HeaderContentType, mimeCSV+"; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("inline; filename=\"forecast_%s.csv\"", name))
+
+	return writeCSV(c.Response().BodyWriter(), series, startTime, stepLength, name == "daily")
+}
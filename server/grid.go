@@ -0,0 +1,205 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"hstin/zephyr/common"
+	"hstin/zephyr/models/base"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/xhhuango/json"
+)
+
+type GridResponse struct {
+	Model     string `json:"model"`
+	Parameter string `json:"parameter"`
+	Unit      string `json:"unit"`
+	// Unix timestamp of the step in milliseconds
+	Time int64 `json:"time"`
+	// Center of the first cell, the south western corner of the grid
+	LatitudeOrigin  float64 `json:"lat_origin"`
+	LongitudeOrigin float64 `json:"lng_origin"`
+	Dx              float64 `json:"dx"`
+	Dy              float64 `json:"dy"`
+	Nx              int     `json:"nx"`
+	Ny              int     `json:"ny"`
+	// Base64 encoded little endian float32 values, row by row from south to north, NaN if missing
+	Values string `json:"values,omitempty"`
+	// Base64 encoded bit mask, one bit per value, least significant bit first, set if the value is present
+	Mask string `json:"mask,omitempty"`
+}
+
+type gridRequest struct {
+	model     common.BaseModel
+	parameter common.ParameterOptions
+	time      time.Time
+	bbox      base.BoundingBox
+	stride    int
+}
+
+// parseBoundingBox parses "west,south,east,north"
+func parseBoundingBox(value string) (base.BoundingBox, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 4 {
+		return base.BoundingBox{}, errors.New("bbox must be west,south,east,north")
+	}
+
+	var coordinates [4]float64
+	for i, part := range parts {
+		coordinate, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return base.BoundingBox{}, errors.New("bbox must be west,south,east,north")
+		}
+		coordinates[i] = coordinate
+	}
+
+	bbox := base.BoundingBox{West: coordinates[0], South: coordinates[1], East: coordinates[2], North: coordinates[3]}
+	return bbox, bbox.Validate()
+}
+
+// parseTime accepts unix timestamps in milliseconds and RFC 3339 times, an empty value is the current time
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Now(), nil
+	}
+
+	if ms, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.UnixMilli(ms), nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, errors.New("invalid time")
+	}
+	return t, nil
+}
+
+// newGridRequest validates the request, the model is selected for the whole box if none is given
+func newGridRequest(parameterName, modelName string, t time.Time, bbox base.BoundingBox, stride int) (gridRequest, error) {
+	parameter, ok := common.Parameters[parameterName]
+	if !ok {
+		return gridRequest{}, errors.New("invalid parameter")
+	}
+
+	if stride < 0 || stride > 1000 {
+		return gridRequest{}, errors.New("invalid stride")
+	}
+
+	var model common.BaseModel
+	if modelName != "" && modelName != "auto" {
+		options, ok := base.AvailableModels[modelName]
+		if !ok {
+			return gridRequest{}, errors.New("invalid model")
+		}
+		model = options.Model
+	} else {
+		model, _ = base.GetBestModelForArea(bbox, t)
+	}
+
+	return gridRequest{
+		model:     model,
+		parameter: parameter,
+		time:      t,
+		bbox:      bbox,
+		stride:    stride,
+	}, nil
+}
+
+func (r gridRequest) logAccess(info *accessInfo) {
+	latitude, longitude := r.bbox.Center()
+	info.setLocation(latitude, longitude)
+	info.parameters = 1
+	if r.model != nil {
+		info.model = r.model.GetModelName()
+	}
+}
+
+func (r gridRequest) grid(ctx context.Context) (base.Grid, error) {
+	return base.GetGrid(ctx, r.model, r.parameter, r.time, r.bbox, r.stride)
+}
+
+func newGridResponse(grid base.Grid) GridResponse {
+	return GridResponse{
+		Model:           grid.Model,
+		Parameter:       grid.Parameter,
+		Unit:            grid.Unit,
+		Time:            grid.Time,
+		LatitudeOrigin:  grid.Latitudes[0],
+		LongitudeOrigin: grid.Longitudes[0],
+		Dx:              grid.Dx,
+		Dy:              grid.Dy,
+		Nx:              grid.Nx(),
+		Ny:              grid.Ny(),
+	}
+}
+
+// gridBinary returns the body of format=binary: the length of the JSON metadata as little endian
+// uint32, the metadata without values and mask, the raw mask and the raw values
+func gridBinary(response GridResponse, grid base.Grid) ([]byte, error) {
+	metadata, err := json.Marshal(response)
+	if err != nil {
+		return nil, err
+	}
+
+	values := grid.ValueBytes()
+
+	body := make([]byte, 4, 4+len(metadata)+len(grid.Mask)+len(values))
+	binary.LittleEndian.PutUint32(body, uint32(len(metadata)))
+	body = append(body, metadata...)
+	body = append(body, grid.Mask...)
+	body = append(body, values...)
+
+	return body, nil
+}
+
+// handleGrid answers GET /grid?bbox=west,south,east,north&param=...&time=...&model=...&stride=...
+// With format=binary the body holds the metadata followed by the raw mask and values, see gridBinary.
+func handleGrid(c *fiber.Ctx) error {
+	bbox, err := parseBoundingBox(c.Query("bbox"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	t, err := parseTime(c.Query("time"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	request, err := newGridRequest(c.Query("param"), c.Query("model"), t, bbox, c.QueryInt("stride", 1))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	request.logAccess(httpAccessInfo(c))
+
+	grid, err := request.grid(c.UserContext())
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	response := newGridResponse(grid)
+
+	switch c.Query("format", "json") {
+	case "json":
+		response.Values = base64.StdEncoding.EncodeToString(grid.ValueBytes())
+		response.Mask = base64.StdEncoding.EncodeToString(grid.Mask)
+		return c.JSON(response)
+	case "binary":
+		body, err := gridBinary(response, grid)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Error encoding metadata"})
+		}
+
+		c.Set("X-Grid-Nx", strconv.Itoa(response.Nx))
+		c.Set("X-Grid-Ny", strconv.Itoa(response.Ny))
+		c.Set(fiber.HeaderContentType, fiber.MIMEOctetStream)
+		return c.Send(body)
+	}
+
+	return c.Status(fiber.StatusNotAcceptable).JSON(fiber.Map{"error": "unsupported format, supported formats are json and binary"})
+}
@@ -6,6 +6,7 @@ import (
 	"hstin/zephyr/common"
 	"hstin/zephyr/models/base"
 	"hstin/zephyr/protobuf"
+	"hstin/zephyr/tracing"
 	"net"
 	"os"
 	"time"
@@ -14,7 +15,10 @@ import (
 
 	"github.com/zsefvlol/timezonemapper"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/structpb"
 )
 
@@ -35,6 +39,11 @@ func (s *server) GetForecast(ctx context.Context, in *protobuf.ForecastRequest)
 		return nil, errors.New("invalid longitude")
 	}
 
+	forecastDays := int(in.ForecastDays)
+	if err := validateForecastDays(forecastDays); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
 	var params []common.ParameterOptions = make([]common.ParameterOptions, 0, len(in.Parameters))
 
 	for _, param := range in.Parameters {
@@ -43,18 +52,30 @@ func (s *server) GetForecast(ctx context.Context, in *protobuf.ForecastRequest)
 		}
 	}
 
-	model, _ := base.GetBestModel(in.Lat, in.Lng, in.Model)
-
+	_, timezoneSpan := tracing.Start(ctx, "timezone lookup")
 	timezone := timezonemapper.LatLngToTimezoneString(in.Lat, in.Lng)
+	timezoneSpan.End()
 
 	matchedParams, err := GetParameterOptions(in.Parameters)
 	if err != nil {
 		return nil, err
 	}
 
-	forecastDays := int(in.ForecastDays)
-	if forecastDays > 365 {
-		return nil, errors.New("Invalid number of days")
+	conditionObjects, err := parseConditionOptions(in.ConditionFormat, in.Lang)
+	if err != nil {
+		return nil, err
+	}
+
+	if conditionObjects {
+		matchedParams = withParameters(matchedParams, conditionParameter)
+	}
+
+	if in.Pv != nil {
+		if err := pvOptionsFromProto(in.Pv).validate(); err != nil {
+			return nil, err
+		}
+
+		matchedParams = withParameters(matchedParams, pvParameters...)
 	}
 
 	loc, err := time.LoadLocation(timezone)
@@ -66,11 +87,55 @@ func (s *server) GetForecast(ctx context.Context, in *protobuf.ForecastRequest)
 
 	_, offset := startTime.Zone()
 
-	dailyParameter, hourlyParameter, usedModels, err := base.GetValues(model, matchedParams, startTime, forecastDays, in.Lat, in.Lng)
+	model, modelName := base.GetBestModel(in.Lat, in.Lng, in.Model, startTime)
+
+	access := grpcAccessInfo(ctx)
+	access.setLocation(in.Lat, in.Lng)
+	access.model = modelName
+	access.parameters = len(matchedParams)
+
+	var dailyParameter, hourlyParameter map[string][]float64
+	var usedModels map[string][]string
+	var modelRanges map[string][]base.ModelRange
+
+	if in.Blend {
+		if in.BlendOverlap < 0 || in.BlendOverlap > 48 {
+			return nil, errors.New("Invalid blend overlap")
+		}
+
+		dailyParameter, hourlyParameter, usedModels, modelRanges, err = base.GetBlendedValues(ctx, model, matchedParams, startTime, forecastDays, in.Lat, in.Lng, int(in.BlendOverlap))
+	} else {
+		dailyParameter, hourlyParameter, usedModels, modelRanges, err = base.GetValues(ctx, model, matchedParams, startTime, forecastDays, in.Lat, in.Lng)
+	}
 	if err != nil {
 		return nil, errors.New("Error getting data")
 	}
 
+	var conditions *protobuf.Conditions
+
+	if conditionObjects {
+		conditions = conditionsToProto(buildConditions(dailyParameter, hourlyParameter, modelRanges, startTime, forecastDays, in.Lat, in.Lng, in.Lang))
+	}
+
+	if in.Pv != nil {
+		addPVEstimate(dailyParameter, hourlyParameter, startTime, forecastDays, in.Lat, in.Lng, pvOptionsFromProto(in.Pv))
+	}
+
+	var astronomy *protobuf.Astronomy
+	var solarPosition *protobuf.SolarPosition
+
+	if in.Astronomy {
+		astronomy = astronomyToProto(buildAstronomy(startTime, forecastDays, in.Lat, in.Lng))
+	}
+
+	if in.SolarPosition {
+		position := buildSolarPosition(startTime, forecastDays, hourlyParameter, in.Lat, in.Lng)
+		solarPosition = &protobuf.SolarPosition{
+			Elevation: position.Elevation,
+			Azimuth:   position.Azimuth,
+		}
+	}
+
 	var daily map[string]*structpb.ListValue = make(map[string]*structpb.ListValue, len(dailyParameter))
 	var hourly map[string]*structpb.ListValue = make(map[string]*structpb.ListValue, len(hourlyParameter))
 	var minutely15 map[string]*structpb.ListValue = make(map[string]*structpb.ListValue, len(hourlyParameter))
@@ -135,6 +200,28 @@ func (s *server) GetForecast(ctx context.Context, in *protobuf.ForecastRequest)
 		}
 	}
 
+	var modelRangesMap map[string]*structpb.ListValue = make(map[string]*structpb.ListValue, len(modelRanges))
+
+	for key, value := range modelRanges {
+		modelRangesMap[key] = &structpb.ListValue{
+			Values: make([]*structpb.Value, len(value)),
+		}
+
+		for i, val := range value {
+			fields := map[string]*structpb.Value{
+				"model": structpb.NewStringValue(val.Model),
+				"from":  structpb.NewNumberValue(float64(val.From)),
+				"to":    structpb.NewNumberValue(float64(val.To)),
+			}
+
+			if val.BlendedWith != "" {
+				fields["blended_with"] = structpb.NewStringValue(val.BlendedWith)
+			}
+
+			modelRangesMap[key].Values[i] = structpb.NewStructValue(&structpb.Struct{Fields: fields})
+		}
+	}
+
 	return &protobuf.ForecastResponse{
 		CalculationTime: int64(time.Since(startCalculation).Microseconds()),
 		Latitude:        in.Lat,
@@ -146,11 +233,124 @@ func (s *server) GetForecast(ctx context.Context, in *protobuf.ForecastRequest)
 		Daily:           daily,
 		Hourly:          hourly,
 		Minutely15:      minutely15,
+		ModelRanges:     modelRangesMap,
+		Conditions:      conditions,
+		Astronomy:       astronomy,
+		SolarPosition:   solarPosition,
+		GridCell:        gridCellToProto(snapToGrid(ctx, model, matchedParams, startTime, in.Lat, in.Lng)),
+	}, nil
+
+}
+
+func gridCellToProto(cell *base.GridCell) *protobuf.GridCell {
+	if cell == nil {
+		return nil
+	}
+
+	return &protobuf.GridCell{
+		Model:     cell.Model,
+		Latitude:  cell.Latitude,
+		Longitude: cell.Longitude,
+	}
+}
+
+func conditionsToProto(conditions *Conditions) *protobuf.Conditions {
+	convert := func(list []*common.Condition) []*protobuf.Condition {
+		result := make([]*protobuf.Condition, len(list))
+		for i, c := range list {
+			if c == nil {
+				result[i] = &protobuf.Condition{Code: -1}
+				continue
+			}
+
+			result[i] = &protobuf.Condition{
+				Code:      int32(c.Code),
+				Text:      c.Text,
+				Icon:      c.Icon,
+				Available: true,
+			}
+		}
+		return result
+	}
+
+	return &protobuf.Conditions{
+		Hourly: convert(conditions.Hourly),
+		Daily:  convert(conditions.Daily),
+	}
+}
+
+func (s *server) GetGrid(ctx context.Context, in *protobuf.GridRequest) (*protobuf.GridResponse, error) {
+	bbox := base.BoundingBox{South: in.South, West: in.West, North: in.North, East: in.East}
+	if err := bbox.Validate(); err != nil {
+		return nil, err
+	}
+
+	t := time.Now()
+	if in.Time != 0 {
+		t = time.UnixMilli(in.Time)
+	}
+
+	request, err := newGridRequest(in.Parameter, in.Model, t, bbox, int(in.Stride))
+	if err != nil {
+		return nil, err
+	}
+
+	request.logAccess(grpcAccessInfo(ctx))
+
+	grid, err := request.grid(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &protobuf.GridResponse{
+		Model:     grid.Model,
+		Parameter: grid.Parameter,
+		Unit:      grid.Unit,
+		Time:      grid.Time,
+		LatOrigin: grid.Latitudes[0],
+		LngOrigin: grid.Longitudes[0],
+		Dx:        grid.Dx,
+		Dy:        grid.Dy,
+		Nx:        int32(grid.Nx()),
+		Ny:        int32(grid.Ny()),
+		Values:    grid.ValueBytes(),
+		Mask:      grid.Mask,
 	}, nil
+}
+
+func pvOptionsFromProto(options *protobuf.PVOptions) PVOptions {
+	return PVOptions{
+		Tilt:    options.Tilt,
+		Azimuth: options.Azimuth,
+		KWp:     options.Kwp,
+	}
+}
+
+func astronomyToProto(astronomy *Astronomy) *protobuf.Astronomy {
+	convert := func(list []*int64) []int64 {
+		result := make([]int64, len(list))
+		for i, t := range list {
+			if t != nil {
+				result[i] = *t
+			}
+		}
+		return result
+	}
 
+	return &protobuf.Astronomy{
+		Sunrise:               convert(astronomy.Sunrise),
+		Sunset:                convert(astronomy.Sunset),
+		CivilTwilightBegin:    convert(astronomy.CivilTwilightBegin),
+		CivilTwilightEnd:      convert(astronomy.CivilTwilightEnd),
+		NauticalTwilightBegin: convert(astronomy.NauticalTwilightBegin),
+		NauticalTwilightEnd:   convert(astronomy.NauticalTwilightEnd),
+		DaylightDuration:      astronomy.DaylightDuration,
+		MoonPhase:             astronomy.MoonPhase,
+		MoonIllumination:      astronomy.MoonIllumination,
+	}
 }
 
-func StartGRPCServer(port string) {
+func StartGRPCServer(port string, options ServerOptions) {
 
 	// Only start the gRPC server once
 	if os.Getenv(grpcEnvVar) != "" {
@@ -160,14 +360,34 @@ func StartGRPCServer(port string) {
 
 	lis, err := net.Listen("tcp", ":"+port)
 	if err != nil {
-		Log.Fatal().Err(err).Msg("failed to start listener")
+		GRPCLog.Fatal().Err(err).Msg("failed to start listener")
+	}
+
+	serverOptions := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(tracingUnaryInterceptor(), accessLogUnaryInterceptor()),
+		grpc.ChainStreamInterceptor(tracingStreamInterceptor(), accessLogStreamInterceptor()),
+	}
+
+	if options.Auth != nil {
+		serverOptions = append(serverOptions,
+			grpc.ChainUnaryInterceptor(authUnaryInterceptor(options.Auth)),
+			grpc.ChainStreamInterceptor(authStreamInterceptor(options.Auth)),
+		)
+	}
+
+	if options.GRPCTLS != nil {
+		reloader, err := newCertificateReloader(*options.GRPCTLS, "h2")
+		if err != nil {
+			GRPCLog.Fatal().Err(err).Msg("failed to load TLS certificate")
+		}
+		serverOptions = append(serverOptions, grpc.Creds(credentials.NewTLS(reloader.serverConfig())))
 	}
 
-	s := grpc.NewServer()
+	s := grpc.NewServer(serverOptions...)
 	protobuf.RegisterForecastServiceServer(s, &server{})
 	reflection.Register(s)
-	Log.Info().Msgf("gRPC server listening at :%s", port)
+	GRPCLog.Info().Msgf("gRPC server listening at :%s", port)
 	if err := s.Serve(lis); err != nil {
-		Log.Fatal().Err(err).Msg("failed to start gRPC server")
+		GRPCLog.Fatal().Err(err).Msg("failed to start gRPC server")
 	}
 }
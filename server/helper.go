@@ -1,11 +1,14 @@
 package server
 
 import (
+	"context"
 	"errors"
 	"hstin/zephyr/common"
+	"hstin/zephyr/models/base"
 	"math"
 	"strings"
 	"sync"
+	"time"
 )
 
 func GetParameterOptions(params []string) ([]common.ParameterOptions, error) {
@@ -29,6 +32,53 @@ func GetParameterOptions(params []string) ([]common.ParameterOptions, error) {
 	return matchedParams, nil
 }
 
+// withParameters adds the registered parameters of names that are not part of params yet
+func withParameters(params []common.ParameterOptions, names ...string) []common.ParameterOptions {
+	for _, name := range names {
+		found := false
+		for _, p := range params {
+			if p.DisplayName == name {
+				found = true
+				break
+			}
+		}
+
+		if p, ok := common.Parameters[name]; ok && !found {
+			params = append(params, p)
+		}
+	}
+
+	return params
+}
+
+// Reasonable number of forecast days
+const maxForecastDays = 365
+
+// validateForecastDays checks the number of forecast days of a request. The builders of the
+// conditions, astronomy and solar position size their series by forecastDays+1 and do not check
+// it themselves, so both servers validate it before any of them runs.
+func validateForecastDays(days int) error {
+	if days < 0 || days > maxForecastDays {
+		return errors.New("Invalid number of days")
+	}
+
+	return nil
+}
+
+// snapToGrid returns the grid cell the values of the first parameter are read from, nil if unknown
+func snapToGrid(ctx context.Context, model common.BaseModel, params []common.ParameterOptions, startTime time.Time, latitude, longitude float64) *base.GridCell {
+	if len(params) == 0 {
+		return nil
+	}
+
+	cell, err := base.GetGridCell(ctx, model, params[0], common.CalculateDaysSinceEpoch(startTime), latitude, longitude)
+	if err != nil {
+		return nil
+	}
+
+	return &cell
+}
+
 func calculate15Minutely(hourlyParameter map[string][]float64) map[string][]float64 {
 
 	var wg sync.WaitGroup
@@ -1,40 +1,62 @@
 package server
 
 import (
+	"crypto/tls"
 	. "hstin/zephyr/helper"
 	"hstin/zephyr/models/base"
+	"hstin/zephyr/tracing"
+	"net"
 	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/xhhuango/json"
 	"github.com/zsefvlol/timezonemapper"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+// ForecastResponse is the answer of /forecast. Latitude and Longitude hold the requested point,
+// GridCell the cell of the model the values are read from.
 type ForecastResponse struct {
-	CalculationTime int64                `json:"calculation_time"`
-	Latitude        float64              `json:"latitude"`
-	Longitude       float64              `json:"longitude"`
-	UTCOffset       int                  `json:"utc_offset"`
-	Timezone        string               `json:"timezone"`
-	StartTime       int64                `json:"start_time"`
-	UsedModels      map[string][]string  `json:"used_models"`
-	Daily           map[string][]float64 `json:"daily"`
-	Hourly          map[string][]float64 `json:"hourly"`
-	Minitely15      map[string][]float64 `json:"minutely15"`
+	CalculationTime int64                        `json:"calculation_time"`
+	Latitude        float64                      `json:"latitude"`
+	Longitude       float64                      `json:"longitude"`
+	UTCOffset       int                          `json:"utc_offset"`
+	Timezone        string                       `json:"timezone"`
+	StartTime       int64                        `json:"start_time"`
+	UsedModels      map[string][]string          `json:"used_models"`
+	Daily           map[string][]float64         `json:"daily"`
+	Hourly          map[string][]float64         `json:"hourly"`
+	Minitely15      map[string][]float64         `json:"minutely15"`
+	ModelRanges     map[string][]base.ModelRange `json:"model_ranges,omitempty"`
+	Conditions      *Conditions                  `json:"conditions,omitempty"`
+	Astronomy       *Astronomy                   `json:"astronomy,omitempty"`
+	SolarPosition   *SolarPosition               `json:"solar_position,omitempty"`
+	GridCell        *base.GridCell               `json:"grid_cell,omitempty"`
 }
 
-func StartServer(port string) {
+func StartServer(port string, options ServerOptions) {
 
+	// Prefork doesn't support custom TLS configs, which are needed to reload certificates.
+	// The rate limits and quotas of the API keys are kept in memory, so with prefork every
+	// process would allow the full limits.
 	app := fiber.New(fiber.Config{
 		JSONEncoder:           json.Marshal,
 		JSONDecoder:           json.Unmarshal,
-		Prefork:               true,
+		Prefork:               options.HTTPTLS == nil && options.Auth == nil,
 		Concurrency:           256 * 1024 * 1024 * 24,
 		DisableStartupMessage: true,
 		ServerHeader:          "zephyr",
 	})
 
+	app.Use(tracingMiddleware())
+	app.Use(accessLogMiddleware())
+
+	if options.Auth != nil {
+		app.Use(authMiddleware(options.Auth))
+		app.Get("/usage", handleUsage(options.Auth))
+	}
+
 	app.Get("/forecast", func(c *fiber.Ctx) error {
 		startCalculation := time.Now()
 		latitude := c.QueryFloat("lat")
@@ -47,7 +69,24 @@ func StartServer(port string) {
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid longitude"})
 		}
 
+		forecastDays := c.QueryInt("forecastDays")
+		if err := validateForecastDays(forecastDays); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		format, err := negotiateFormat(c)
+		if err != nil {
+			return c.Status(fiber.StatusNotAcceptable).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		access := httpAccessInfo(c)
+		access.setLocation(latitude, longitude)
+
+		ctx := c.UserContext()
+
+		_, timezoneSpan := tracing.Start(ctx, "timezone lookup")
 		timezone := timezonemapper.LatLngToTimezoneString(latitude, longitude)
+		timezoneSpan.End()
 		params := c.Query("params")
 
 		if params == "" {
@@ -64,9 +103,29 @@ func StartServer(port string) {
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 		}
 
-		forecastDays := c.QueryInt("forecastDays")
-		if forecastDays > 365 { // Reasonable number of forecast days
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid number of days"})
+		conditionObjects, err := parseConditionOptions(c.Query("condition_format"), c.Query("lang"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		if conditionObjects {
+			matchedParams = withParameters(matchedParams, conditionParameter)
+		}
+
+		var pvOptions *PVOptions
+
+		if c.QueryBool("pv") {
+			pvOptions = &PVOptions{
+				Tilt:    c.QueryFloat("tilt", 30),
+				Azimuth: c.QueryFloat("azimuth", 180),
+				KWp:     c.QueryFloat("kwp", 1),
+			}
+
+			if err := pvOptions.validate(); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+			}
+
+			matchedParams = withParameters(matchedParams, pvParameters...)
 		}
 
 		loc, err := time.LoadLocation(timezone)
@@ -78,11 +137,78 @@ func StartServer(port string) {
 
 		_, offset := startTime.Zone()
 
-		model, _ := base.GetBestModel(latitude, longitude, c.Query("model"))
+		model, modelName := base.GetBestModel(latitude, longitude, c.Query("model"), startTime)
 
-		dailyParameter, hourlyParameter, usedModels, err := base.GetValues(model, matchedParams, startTime, forecastDays, latitude, longitude)
-		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Error getting data"})
+		access.model = modelName
+		access.parameters = len(matchedParams)
+
+		blend := c.QueryBool("blend")
+		blendOverlap := c.QueryInt("blend_overlap")
+		if blend && (blendOverlap < 0 || blendOverlap > 48) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid blend overlap"})
+		}
+
+		// The version is determined before reading, so data of a run ingested meanwhile is never cached as the old version
+		version := base.GetDataVersion(model)
+
+		etag := forecastETag(c, format, version, startTime)
+		if notModified(c, etag, version.LastModified) {
+			setCacheHeaders(c, etag, version, options.Auth != nil)
+			return c.SendStatus(fiber.StatusNotModified)
+		}
+
+		var dailyParameter, hourlyParameter map[string][]float64
+		var usedModels map[string][]string
+		var modelRanges map[string][]base.ModelRange
+
+		// Values are read for the grid cells that contain the point, so requests in the same cells share them
+		cells := forecastCells(ctx, model, matchedParams, startTime, latitude, longitude)
+		cacheKey := valuesCacheKey(cells, matchedParams, startTime, forecastDays, blend, blendOverlap)
+
+		if cached, ok := valuesCache.Get(cacheKey); ok && cached.version.ID == version.ID {
+			dailyParameter, hourlyParameter, usedModels, modelRanges = cached.get()
+		} else {
+			if blend {
+				dailyParameter, hourlyParameter, usedModels, modelRanges, err = base.GetBlendedValues(ctx, model, matchedParams, startTime, forecastDays, latitude, longitude, blendOverlap)
+			} else {
+				dailyParameter, hourlyParameter, usedModels, modelRanges, err = base.GetValues(ctx, model, matchedParams, startTime, forecastDays, latitude, longitude)
+			}
+			if err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Error getting data"})
+			}
+
+			if cached := newCachedValues(version, dailyParameter, hourlyParameter, usedModels, modelRanges); cached != nil {
+				valuesCache.Add(cacheKey, cached)
+			}
+		}
+
+		// Every successful response carries the headers, also if its values are too large to be cached
+		setCacheHeaders(c, etag, version, options.Auth != nil)
+
+		var gridCell *base.GridCell
+		if len(cells) > 0 {
+			gridCell = &cells[0]
+		}
+
+		var conditions *Conditions
+
+		if conditionObjects {
+			conditions = buildConditions(dailyParameter, hourlyParameter, modelRanges, startTime, forecastDays, latitude, longitude, c.Query("lang"))
+		}
+
+		if pvOptions != nil {
+			addPVEstimate(dailyParameter, hourlyParameter, startTime, forecastDays, latitude, longitude, *pvOptions)
+		}
+
+		var astronomy *Astronomy
+		var solarPosition *SolarPosition
+
+		if c.QueryBool("astronomy") {
+			astronomy = buildAstronomy(startTime, forecastDays, latitude, longitude)
+		}
+
+		if c.QueryBool("solar_position") {
+			solarPosition = buildSolarPosition(startTime, forecastDays, hourlyParameter, latitude, longitude)
 		}
 
 		var minutely15 map[string][]float64 = make(map[string][]float64, 0)
@@ -91,7 +217,7 @@ func StartServer(port string) {
 			minutely15 = calculate15Minutely(hourlyParameter)
 		}
 
-		return c.JSON(ForecastResponse{
+		response := ForecastResponse{
 			CalculationTime: time.Since(startCalculation).Microseconds(),
 			Latitude:        latitude,
 			Longitude:       longitude,
@@ -102,10 +228,45 @@ func StartServer(port string) {
 			Daily:           dailyParameter,
 			Hourly:          hourlyParameter,
 			Minitely15:      minutely15,
-		})
+			ModelRanges:     modelRanges,
+			Conditions:      conditions,
+			Astronomy:       astronomy,
+			SolarPosition:   solarPosition,
+			GridCell:        gridCell,
+		}
+
+		_, encodeSpan := tracing.Start(ctx, "encode response", attribute.String("format", format))
+		if format == formatCSV || format == formatArrow {
+			err = sendSeries(c, format, response, startTime, forecastDays)
+		} else {
+			err = c.JSON(response)
+		}
+		tracing.End(encodeSpan, err)
+
+		return err
 	})
 
-	Log.Info().Msg("HTTP server started on port " + port)
+	app.Get("/grid", handleGrid)
+	app.Get("/tiles/:param/:time/:z/:x/:y.png", handleTile)
+	app.Get("/wind", handleWind)
+
+	if options.HTTPTLS != nil {
+		reloader, err := newCertificateReloader(*options.HTTPTLS)
+		if err != nil {
+			HTTPLog.Fatal().Err(err).Msg("Failed to load TLS certificate")
+		}
+
+		ln, err := net.Listen("tcp", ":"+port)
+		if err != nil {
+			HTTPLog.Fatal().Err(err).Msg("Failed to start HTTP server")
+		}
+
+		HTTPLog.Info().Msg("HTTPS server started on port " + port)
+
+		HTTPLog.Fatal().Err(app.Listener(tls.NewListener(ln, reloader.serverConfig()))).Msg("Failed to start HTTP server")
+	}
+
+	HTTPLog.Info().Msg("HTTP server started on port " + port)
 
-	Log.Fatal().Err(app.Listen(":" + port)).Msg("Failed to start HTTP server")
+	HTTPLog.Fatal().Err(app.Listen(":" + port)).Msg("Failed to start HTTP server")
 }
@@ -0,0 +1,12 @@
+package server
+
+import "hstin/zephyr/auth"
+
+// ServerOptions are shared by the HTTP and the gRPC server
+type ServerOptions struct {
+	// Requests are checked against the API keys of the store, nil disables authentication
+	Auth *auth.Store
+	// TLS of the HTTP and gRPC listener, nil serves plaintext
+	HTTPTLS *TLSOptions
+	GRPCTLS *TLSOptions
+}
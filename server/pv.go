@@ -0,0 +1,108 @@
+package server
+
+import (
+	"errors"
+	"hstin/zephyr/astro"
+	"math"
+	"time"
+)
+
+const (
+	shortwaveParameter = "shortwave_radiation"
+	directParameter    = "direct_radiation"
+	diffuseParameter   = "diffuse_radiation"
+
+	// Share of the energy on the modules that is fed in, covers inverter, cable, temperature and soiling losses
+	pvPerformanceRatio = 0.85
+	groundAlbedo       = 0.2
+)
+
+// PVOptions describes the modules of a PV system
+type PVOptions struct {
+	// Angle to the horizontal in degrees
+	Tilt float64
+	// Orientation clockwise from north in degrees, 180 = south
+	Azimuth float64
+	// Peak power in kW
+	KWp float64
+}
+
+func (o PVOptions) validate() error {
+	if o.Tilt < 0 || o.Tilt > 90 {
+		return errors.New("invalid tilt")
+	}
+
+	if o.Azimuth < 0 || o.Azimuth > 360 {
+		return errors.New("invalid azimuth")
+	}
+
+	if o.KWp <= 0 || o.KWp > 100000 {
+		return errors.New("invalid kwp")
+	}
+
+	return nil
+}
+
+// pvParameters are the irradiance parameters used for the PV estimate
+var pvParameters = []string{shortwaveParameter, directParameter, diffuseParameter}
+
+// addPVEstimate adds the global tilted irradiance (W/m^2) and the PV power (kW) to the hourly values
+// and the PV energy (kWh) per day to the daily values. The irradiance values are the means of the
+// preceding step, the position of the sun is taken in the middle of the step. If the model has
+// no direct and diffuse radiation, the global radiation is split into both.
+func addPVEstimate(daily, hourly map[string][]float64, startTime time.Time, forecastDays int, latitude, longitude float64, options PVOptions) {
+	direct, hasDirect := hourly[directParameter]
+	diffuse, hasDiffuse := hourly[diffuseParameter]
+	global, hasGlobal := hourly[shortwaveParameter]
+
+	var steps int
+	switch {
+	case hasDirect && hasDiffuse && len(direct) == len(diffuse):
+		steps = len(direct)
+	case hasGlobal:
+		hasDirect, hasDiffuse = false, false
+		steps = len(global)
+	default:
+		return
+	}
+
+	stepsPerDay := steps / (forecastDays + 1)
+	if stepsPerDay == 0 {
+		return
+	}
+
+	start := startTime.Truncate(24 * time.Hour)
+	stepLength := time.Duration(forecastDays+1) * 24 * time.Hour / time.Duration(steps)
+
+	tilted := make([]float64, steps)
+	power := make([]float64, steps)
+	energy := make([]float64, forecastDays+1)
+
+	for j := 0; j < steps; j++ {
+		t := start.Add(time.Duration(j)*stepLength - stepLength/2)
+		elevation, azimuth := astro.SolarPosition(t, latitude, longitude)
+
+		var directValue, diffuseValue float64
+		if hasDirect && hasDiffuse {
+			directValue, diffuseValue = direct[j], diffuse[j]
+		} else {
+			directValue, diffuseValue = astro.SplitGlobalIrradiance(global[j], t, elevation)
+		}
+
+		irradiance := astro.TiltedIrradiance(directValue, diffuseValue, elevation, azimuth, options.Tilt, options.Azimuth, groundAlbedo)
+		kw := options.KWp * irradiance / 1000 * pvPerformanceRatio
+
+		tilted[j] = math.Round(irradiance*10) / 10
+		power[j] = math.Round(kw*1000) / 1000
+
+		energy[j/stepsPerDay] += kw * stepLength.Hours()
+	}
+
+	for day := range energy {
+		energy[day] = math.Round(energy[day]*1000) / 1000
+	}
+
+	hourly["global_tilted_irradiance"] = tilted
+	hourly["pv_power"] = power
+	daily["pv_energy"] = energy
+}
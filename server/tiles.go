@@ -0,0 +1,117 @@
+package server
+
+import (
+	"fmt"
+	"hstin/zephyr/cache"
+	"hstin/zephyr/common"
+	"hstin/zephyr/models/base"
+	"hstin/zephyr/tiles"
+	"math"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Rendered tiles are kept for a few minutes, so tiles of new model runs show up quickly
+var tileCache = cache.NewLRU[[]byte](4096, 10*time.Minute)
+
+// Resolution used if the model has none configured
+const defaultTileResolution = 0.25
+
+// tileBounds returns the area of the grid needed to render the tile, the tile extended by margin degrees
+func tileBounds(z, x, y int, margin float64) base.BoundingBox {
+	bbox := tiles.Bounds(z, x, y)
+
+	bbox.South = math.Max(bbox.South-margin, -90)
+	bbox.North = math.Min(bbox.North+margin, 90)
+
+	if bbox.East-bbox.West+2*margin >= 360 {
+		bbox.West, bbox.East = -180, 180
+		return bbox
+	}
+
+	bbox.West = math.Mod(bbox.West-margin+540, 360) - 180
+	bbox.East = math.Mod(bbox.East+margin+540, 360) - 180
+
+	return bbox
+}
+
+// handleTile answers GET /tiles/:param/:time/:z/:x/:y.png with a Web Mercator tile of the step that contains time.
+// time is a unix timestamp in milliseconds, an RFC 3339 time or "now", the model can be selected with ?model=.
+func handleTile(c *fiber.Ctx) error {
+	parameter, ok := common.Parameters[c.Params("param")]
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Unknown parameter"})
+	}
+
+	if len(parameter.ColorRamp) == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "No color ramp configured for the parameter"})
+	}
+
+	timeParam := c.Params("time")
+	if timeParam == "now" {
+		timeParam = ""
+	}
+
+	t, err := parseTime(timeParam)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	z, errZ := c.ParamsInt("z")
+	x, errX := c.ParamsInt("x")
+	y, errY := c.ParamsInt("y")
+	if errZ != nil || errX != nil || errY != nil || !tiles.ValidTile(z, x, y) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid tile"})
+	}
+
+	modelName := c.Query("model")
+	if modelName == "auto" {
+		modelName = ""
+	}
+
+	var model common.BaseModel
+	if modelName != "" {
+		options, ok := base.AvailableModels[modelName]
+		if !ok {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid model"})
+		}
+		model = options.Model
+	} else {
+		model, modelName = base.GetBestModelForArea(tiles.Bounds(z, x, y), t)
+	}
+
+	key := fmt.Sprintf("%s/%s/%d/%d/%d/%d", parameter.DisplayName, modelName, t.Truncate(time.Minute).UnixMilli(), z, x, y)
+
+	if data, ok := tileCache.Get(key); ok {
+		return sendTile(c, data)
+	}
+
+	resolution := base.AvailableModels[modelName].Resolution
+	if resolution <= 0 {
+		resolution = defaultTileResolution
+	}
+
+	// Skip cells that are smaller than a pixel
+	stride := max(1, int(tiles.Resolution(z)/resolution))
+
+	grid, err := base.GetGrid(c.UserContext(), model, parameter, t, tileBounds(z, x, y, 2*resolution*float64(stride)), stride)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	data, err := tiles.Render(grid, parameter, z, x, y)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Error rendering tile"})
+	}
+
+	tileCache.Add(key, data)
+
+	return sendTile(c, data)
+}
+
+func sendTile(c *fiber.Ctx, data []byte) error {
+	c.Set(fiber.HeaderContentType, "image/png")
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return c.Send(data)
+}
@@ -0,0 +1,154 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	. "hstin/zephyr/helper"
+)
+
+// Interval in which the certificate files are checked for changes
+const certificateCheckInterval = 30 * time.Second
+
+type TLSOptions struct {
+	CertFile string
+	KeyFile  string
+	// PEM file with the CAs that sign client certificates, clients have to present a valid
+	// certificate if set
+	ClientCAFile string
+}
+
+func (o TLSOptions) files() []string {
+	files := []string{o.CertFile, o.KeyFile}
+	if o.ClientCAFile != "" {
+		files = append(files, o.ClientCAFile)
+	}
+	return files
+}
+
+// certificateReloader serves the certificate and client CAs from disk and loads them again
+// when the files change, rotated certificates are used for new connections without a restart
+type certificateReloader struct {
+	options    TLSOptions
+	nextProtos []string
+
+	config   atomic.Pointer[tls.Config]
+	modTimes []time.Time
+}
+
+func newCertificateReloader(options TLSOptions, nextProtos ...string) (*certificateReloader, error) {
+	if options.CertFile == "" || options.KeyFile == "" {
+		return nil, errors.New("tls: certificate and key file are required")
+	}
+
+	r := &certificateReloader{options: options, nextProtos: nextProtos}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+
+	go r.watch()
+
+	return r, nil
+}
+
+func (r *certificateReloader) load() error {
+	modTimes, err := r.stat()
+	if err != nil {
+		return err
+	}
+
+	certificate, err := tls.LoadX509KeyPair(r.options.CertFile, r.options.KeyFile)
+	if err != nil {
+		return fmt.Errorf("tls: %w", err)
+	}
+
+	config := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{certificate},
+		NextProtos:   r.nextProtos,
+	}
+
+	if r.options.ClientCAFile != "" {
+		data, err := os.ReadFile(r.options.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("tls: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return fmt.Errorf("tls: no certificates found in %s", r.options.ClientCAFile)
+		}
+
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	r.config.Store(config)
+	r.modTimes = modTimes
+
+	return nil
+}
+
+func (r *certificateReloader) stat() ([]time.Time, error) {
+	files := r.options.files()
+	modTimes := make([]time.Time, len(files))
+
+	for i, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, fmt.Errorf("tls: %w", err)
+		}
+		modTimes[i] = info.ModTime()
+	}
+
+	return modTimes, nil
+}
+
+// watch reloads the files when one of them changed, the current certificate is kept if the
+// new files can't be loaded, e.g. while only the certificate but not yet the key was replaced
+func (r *certificateReloader) watch() {
+	ticker := time.NewTicker(certificateCheckInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		modTimes, err := r.stat()
+		if err != nil {
+			Log.Warn().Err(err).Msg("Failed to check TLS certificate")
+			continue
+		}
+
+		changed := false
+		for i := range modTimes {
+			if !modTimes[i].Equal(r.modTimes[i]) {
+				changed = true
+			}
+		}
+
+		if !changed {
+			continue
+		}
+
+		if err := r.load(); err != nil {
+			Log.Warn().Err(err).Msg("Failed to reload TLS certificate, keeping the current one")
+			continue
+		}
+
+		Log.Info().Msgf("Reloaded TLS certificate %s", r.options.CertFile)
+	}
+}
+
+// serverConfig returns the config of the listener, every handshake uses the latest loaded files
+func (r *certificateReloader) serverConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: r.nextProtos,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return r.config.Load(), nil
+		},
+	}
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"context"
+	"hstin/zephyr/tracing"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.25.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// headerCarrier reads the trace context from the request headers of a fiber request
+type headerCarrier struct {
+	c *fiber.Ctx
+}
+
+func (h headerCarrier) Get(key string) string {
+	return h.c.Get(key)
+}
+
+func (h headerCarrier) Set(key, value string) {
+	h.c.Request().Header.Set(key, value)
+}
+
+func (h headerCarrier) Keys() []string {
+	var keys []string
+	h.c.Request().Header.VisitAll(func(key, _ []byte) {
+		keys = append(keys, string(key))
+	})
+	return keys
+}
+
+// metadataCarrier reads the trace context from the metadata of a gRPC request
+type metadataCarrier metadata.MD
+
+func (m metadataCarrier) Get(key string) string {
+	values := metadata.MD(m).Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func (m metadataCarrier) Set(key, value string) {
+	metadata.MD(m).Set(key, value)
+}
+
+func (m metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// tracingMiddleware starts a span per HTTP request, the handlers find it in c.UserContext()
+func tracingMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, span := tracing.StartServer(c.UserContext(), headerCarrier{c}, "HTTP "+c.Method(),
+			semconv.HTTPRequestMethodKey.String(c.Method()),
+			semconv.URLPath(c.Path()),
+		)
+		defer span.End()
+
+		c.SetUserContext(ctx)
+
+		err := c.Next()
+
+		route := c.Route().Path
+		span.SetName(c.Method() + " " + route)
+		span.SetAttributes(semconv.HTTPRoute(route), semconv.HTTPResponseStatusCode(c.Response().StatusCode()))
+
+		if err != nil {
+			span.RecordError(err)
+		}
+		if err != nil || c.Response().StatusCode() >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, "")
+		}
+
+		return err
+	}
+}
+
+func startGRPCSpan(ctx context.Context, method string) (context.Context, trace.Span) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	service, name, _ := strings.Cut(strings.TrimPrefix(method, "/"), "/")
+
+	return tracing.StartServer(ctx, metadataCarrier(md.Copy()), method,
+		semconv.RPCSystemGRPC,
+		semconv.RPCService(service),
+		semconv.RPCMethod(name),
+	)
+}
+
+func endGRPCSpan(span trace.Span, err error) {
+	code := status.Code(err)
+	span.SetAttributes(attribute.Int64(string(semconv.RPCGRPCStatusCodeKey), int64(code)))
+	tracing.End(span, err)
+}
+
+func tracingUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, span := startGRPCSpan(ctx, info.FullMethod)
+
+		resp, err := handler(ctx, req)
+
+		endGRPCSpan(span, err)
+		return resp, err
+	}
+}
+
+func tracingStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		_, span := startGRPCSpan(ss.Context(), info.FullMethod)
+
+		err := handler(srv, ss)
+
+		endGRPCSpan(span, err)
+		return err
+	}
+}
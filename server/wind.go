@@ -0,0 +1,152 @@
+package server
+
+import (
+	"errors"
+	"hstin/zephyr/common"
+	"hstin/zephyr/models/base"
+	"math"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	windUParameter = "wind_u"
+	windVParameter = "wind_v"
+)
+
+// VelocityHeader follows the header of the JSON produced by grib2json, as used by leaflet-velocity
+type VelocityHeader struct {
+	ParameterCategory int     `json:"parameterCategory"`
+	ParameterNumber   int     `json:"parameterNumber"`
+	ParameterUnit     string  `json:"parameterUnit"`
+	Lo1               float64 `json:"lo1"`
+	La1               float64 `json:"la1"`
+	Lo2               float64 `json:"lo2"`
+	La2               float64 `json:"la2"`
+	Dx                float64 `json:"dx"`
+	Dy                float64 `json:"dy"`
+	Nx                int     `json:"nx"`
+	Ny                int     `json:"ny"`
+	RefTime           string  `json:"refTime"`
+	ForecastTime      int     `json:"forecastTime"`
+	Model             string  `json:"model"`
+}
+
+// VelocityComponent holds one wind component row by row from north to south, null if missing
+type VelocityComponent struct {
+	Header VelocityHeader `json:"header"`
+	Data   []*float32     `json:"data"`
+}
+
+func newVelocityComponent(grid base.Grid, parameter common.ParameterOptions) VelocityComponent {
+	nx, ny := grid.Nx(), grid.Ny()
+
+	component := VelocityComponent{
+		Header: VelocityHeader{
+			ParameterCategory: parameter.Category,
+			ParameterNumber:   parameter.Number,
+			ParameterUnit:     parameter.Unit,
+			Lo1:               grid.Longitudes[0],
+			La1:               grid.Latitudes[ny-1],
+			Lo2:               grid.Longitudes[nx-1],
+			La2:               grid.Latitudes[0],
+			Dx:                grid.Dx,
+			Dy:                grid.Dy,
+			Nx:                nx,
+			Ny:                ny,
+			RefTime:           time.UnixMilli(grid.Time).UTC().Format(time.RFC3339),
+			Model:             grid.Model,
+		},
+		Data: make([]*float32, 0, nx*ny),
+	}
+
+	// The grid starts in the south, the data starts in the north
+	for row := ny - 1; row >= 0; row-- {
+		for column := 0; column < nx; column++ {
+			value := grid.Values[row*nx+column]
+			if math.IsNaN(float64(value)) {
+				component.Data = append(component.Data, nil)
+				continue
+			}
+			component.Data = append(component.Data, &value)
+		}
+	}
+
+	return component
+}
+
+// windParameters returns the wind components of the level, e.g. "850hPa" or "" for 10 m above ground
+func windParameters(level string) (common.ParameterOptions, common.ParameterOptions, error) {
+	uName, vName := windUParameter, windVParameter
+	if level != "" {
+		uName += "_" + level
+		vName += "_" + level
+	}
+
+	u, uOk := common.Parameters[uName]
+	v, vOk := common.Parameters[vName]
+	if !uOk || !vOk {
+		return u, v, errors.New("invalid level")
+	}
+
+	return u, v, nil
+}
+
+// handleWind answers GET /wind?bbox=west,south,east,north&time=...&level=...&resolution=...&model=...
+// with the u and v components of the wind in the layout of leaflet-velocity. resolution is the
+// distance between the returned cells in degrees, the grid of the model is downsampled to it.
+func handleWind(c *fiber.Ctx) error {
+	bbox, err := parseBoundingBox(c.Query("bbox"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	t, err := parseTime(c.Query("time"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	u, v, err := windParameters(c.Query("level"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	resolution := c.QueryFloat("resolution", 0)
+	if resolution < 0 || resolution > 10 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid resolution"})
+	}
+
+	uRequest, err := newGridRequest(u.DisplayName, c.Query("model"), t, bbox, 1)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	modelResolution := base.AvailableModels[uRequest.model.GetModelName()].Resolution
+	if modelResolution > 0 {
+		uRequest.stride = max(1, int(math.Round(resolution/modelResolution)))
+	}
+
+	// Both components are read from the model selected for u, so they share the same grid
+	vRequest := uRequest
+	vRequest.parameter = v
+
+	uGrid, err := uRequest.grid(c.UserContext())
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	vGrid, err := vRequest.grid(c.UserContext())
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if uGrid.Model != vGrid.Model || uGrid.Nx() != vGrid.Nx() || uGrid.Ny() != vGrid.Ny() {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "The wind components are not available on the same grid"})
+	}
+
+	return c.JSON([]VelocityComponent{
+		newVelocityComponent(uGrid, u),
+		newVelocityComponent(vGrid, v),
+	})
+}
@@ -0,0 +1,87 @@
+package source
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Longer Retry-After delays of a server are cut to this value
+const maxRetryAfter = 5 * time.Minute
+
+// HTTPSource reads the files from a web server, e.g. the open data server of a provider or a mirror
+type HTTPSource struct {
+	baseURL string
+	host    string
+	client  *http.Client
+}
+
+// NewHTTP returns a source for the files below baseURL, a nil client uses a client with a timeout of 5 minutes
+func NewHTTP(baseURL string, client *http.Client) *HTTPSource {
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Minute}
+	}
+
+	host := baseURL
+	if u, err := url.Parse(baseURL); err == nil {
+		host = u.Host
+	}
+
+	return &HTTPSource{baseURL: strings.TrimSuffix(baseURL, "/"), host: host, client: client}
+}
+
+func (s *HTTPSource) Open(ctx context.Context, path string, r *Range) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.baseURL+"/"+strings.TrimPrefix(path, "/"), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return get(s.client, req, r)
+}
+
+func (s *HTTPSource) Host() string {
+	return s.host
+}
+
+// get sends the request and returns the body if the server answered with the whole file
+// or, if r is not nil, with the requested range
+func get(client *http.Client, req *http.Request, r *Range) (io.ReadCloser, error) {
+	expected := http.StatusOK
+	if r != nil {
+		req.Header.Set("Range", r.header())
+		expected = http.StatusPartialContent
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, &TransportError{Err: err}
+	}
+
+	if resp.StatusCode != expected {
+		resp.Body.Close()
+		return nil, &StatusError{StatusCode: resp.StatusCode, RetryAfter: retryAfter(resp.Header.Get("Retry-After"))}
+	}
+
+	return transportBody{resp.Body}, nil
+}
+
+// retryAfter parses the delay in seconds or the date of a Retry-After header
+func retryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	var delay time.Duration
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		delay = time.Duration(seconds) * time.Second
+	} else if date, err := http.ParseTime(value); err == nil {
+		delay = time.Until(date)
+	}
+
+	return max(0, min(delay, maxRetryAfter))
+}
@@ -0,0 +1,46 @@
+package source
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// LocalSource reads the files from a directory with the same layout as the server of the provider
+type LocalSource struct {
+	dir string
+}
+
+func NewLocal(dir string) *LocalSource {
+	return &LocalSource{dir: dir}
+}
+
+func (s *LocalSource) Open(ctx context.Context, path string, r *Range) (io.ReadCloser, error) {
+	file, err := os.Open(filepath.Join(s.dir, filepath.FromSlash(path)))
+	if err != nil {
+		return nil, err
+	}
+
+	if r == nil {
+		return file, nil
+	}
+
+	if _, err := file.Seek(r.Start, io.SeekStart); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	if r.End < r.Start {
+		return file, nil
+	}
+
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(file, r.End-r.Start+1), file}, nil
+}
+
+func (s *LocalSource) Host() string {
+	return ""
+}
@@ -0,0 +1,144 @@
+package source
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// SHA-256 of the empty body of GET requests
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// S3Source reads the objects of a bucket from an S3-compatible endpoint using path-style URLs.
+// Requests are signed with AWS Signature Version 4 if credentials are set, public buckets
+// are read without credentials.
+type S3Source struct {
+	endpoint  *url.URL
+	bucket    string
+	region    string
+	prefix    string
+	accessKey string
+	secretKey string
+	client    *http.Client
+}
+
+// NewS3 returns a source for the bucket of options. The credentials default to the
+// AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY environment variables, the region to us-east-1.
+func NewS3(options Options) (*S3Source, error) {
+	if options.URL == "" || options.Bucket == "" {
+		return nil, errors.New("s3 sources require a url and a bucket")
+	}
+
+	endpoint, err := url.Parse(strings.TrimSuffix(options.URL, "/"))
+	if err != nil {
+		return nil, err
+	}
+
+	s := &S3Source{
+		endpoint:  endpoint,
+		bucket:    options.Bucket,
+		region:    options.Region,
+		prefix:    strings.Trim(options.Prefix, "/"),
+		accessKey: options.AccessKey,
+		secretKey: options.SecretKey,
+		client:    options.Client,
+	}
+
+	if s.region == "" {
+		s.region = "us-east-1"
+	}
+	if s.accessKey == "" && s.secretKey == "" {
+		s.accessKey = os.Getenv("AWS_ACCESS_KEY_ID")
+		s.secretKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+	}
+	if s.client == nil {
+		s.client = &http.Client{Timeout: 5 * time.Minute}
+	}
+
+	return s, nil
+}
+
+func (s *S3Source) Open(ctx context.Context, path string, r *Range) (io.ReadCloser, error) {
+	key := strings.TrimPrefix(path, "/")
+	if s.prefix != "" {
+		key = s.prefix + "/" + key
+	}
+
+	u := *s.endpoint
+	u.Path = u.Path + "/" + s.bucket + "/" + key
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if r != nil {
+		req.Header.Set("Range", r.header())
+	}
+
+	if s.accessKey != "" {
+		s.sign(req, time.Now().UTC())
+	}
+
+	return get(s.client, req, r)
+}
+
+func (s *S3Source) Host() string {
+	return s.endpoint.Host
+}
+
+// sign adds the AWS Signature Version 4 headers to the request
+func (s *S3Source) sign(req *http.Request, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	scope := date + "/" + s.region + "/s3/aws4_request"
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", emptyPayloadHash)
+
+	signedHeaders := "host"
+	canonicalHeaders := "host:" + req.URL.Host + "\n"
+
+	if value := req.Header.Get("Range"); value != "" {
+		signedHeaders += ";range"
+		canonicalHeaders += "range:" + value + "\n"
+	}
+
+	signedHeaders += ";x-amz-content-sha256;x-amz-date"
+	canonicalHeaders += "x-amz-content-sha256:" + emptyPayloadHash + "\n" + "x-amz-date:" + amzDate + "\n"
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.Query().Encode(),
+		canonicalHeaders,
+		signedHeaders,
+		emptyPayloadHash,
+	}, "\n")
+
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), date)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+s.accessKey+"/"+scope+", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,151 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	TypeHTTP  = "http"
+	TypeLocal = "local"
+	TypeS3    = "s3"
+)
+
+// Source provides the files of a provider. Paths are relative to the root of the provider
+// and use slashes, e.g. "weather/nwp/icon/grib/00/t_2m/<file>.grib2.bz2".
+type Source interface {
+	// Open returns the content of the file, only the given byte range if r is not nil
+	Open(ctx context.Context, path string, r *Range) (io.ReadCloser, error)
+	// Host is used to limit the parallel requests per server, empty for local sources
+	Host() string
+}
+
+// Range selects the bytes from Start to End, both inclusive. An End before Start
+// selects everything from Start to the end of the file.
+type Range struct {
+	Start int64
+	End   int64
+}
+
+func (r Range) header() string {
+	if r.End < r.Start {
+		return fmt.Sprintf("bytes=%d-", r.Start)
+	}
+	return fmt.Sprintf("bytes=%d-%d", r.Start, r.End)
+}
+
+// StatusError is returned for responses with an unexpected status code
+type StatusError struct {
+	StatusCode int
+	// Delay requested by the Retry-After header of the response, 0 if not sent
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status code: %d", e.StatusCode)
+}
+
+// Retryable reports whether a request with this status may succeed later
+func (e *StatusError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// TransportError is returned if a request could not be sent or the body could not be read completely
+type TransportError struct {
+	Err error
+}
+
+func (e *TransportError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *TransportError) Unwrap() error {
+	return e.Err
+}
+
+// Retryable reports whether the file may be available when the request is repeated. Only network
+// errors, including incomplete reads of the body, and responses with status 429 or 5xx are
+// retryable. Missing files, client errors and errors of a completely received file are not.
+func Retryable(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Retryable()
+	}
+
+	var transportErr *TransportError
+	return errors.As(err, &transportErr)
+}
+
+// transportBody marks the read errors of a response body as transport errors
+type transportBody struct {
+	io.ReadCloser
+}
+
+func (b transportBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && err != io.EOF {
+		err = &TransportError{Err: err}
+	}
+	return n, err
+}
+
+type Options struct {
+	// One of "http", "local" or "s3"
+	Type string
+	// Base URL of the mirror (http) or endpoint (s3)
+	URL string
+	// Root directory of a local source
+	Path string
+	// S3 only
+	Bucket    string
+	Region    string
+	Prefix    string
+	AccessKey string
+	SecretKey string
+	// Used by http and s3 sources, defaults to a client with a timeout of 5 minutes
+	Client *http.Client
+}
+
+// New returns the source described by options
+func New(options Options) (Source, error) {
+	switch options.Type {
+	case TypeHTTP:
+		if options.URL == "" {
+			return nil, errors.New("http sources require a url")
+		}
+		return NewHTTP(options.URL, options.Client), nil
+	case TypeLocal:
+		if options.Path == "" {
+			return nil, errors.New("local sources require a path")
+		}
+		return NewLocal(options.Path), nil
+	case TypeS3:
+		s3, err := NewS3(options)
+		if err != nil {
+			return nil, err
+		}
+		return s3, nil
+	}
+
+	return nil, fmt.Errorf("unknown source type '%s'", options.Type)
+}
+
+// SplitURL splits an absolute URL format into the base URL of the server and the path format,
+// ok is false if format is not an absolute http(s) URL
+func SplitURL(format string) (baseURL string, pathFormat string, ok bool) {
+	for _, scheme := range []string{"http://", "https://"} {
+		if !strings.HasPrefix(format, scheme) {
+			continue
+		}
+
+		host, pathFormat, _ := strings.Cut(strings.TrimPrefix(format, scheme), "/")
+		return scheme + host, pathFormat, true
+	}
+
+	return "", format, false
+}
@@ -0,0 +1,34 @@
+package source
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+)
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"too many requests", &StatusError{StatusCode: 429}, true},
+		{"server error", &StatusError{StatusCode: 503}, true},
+		{"not found", &StatusError{StatusCode: 404}, false},
+		{"request timeout", &StatusError{StatusCode: 408}, false},
+		{"network error", &TransportError{Err: errors.New("connection reset")}, true},
+		{"incomplete body", fmt.Errorf("copying file: %w", &TransportError{Err: io.ErrUnexpectedEOF}), true},
+		{"missing local file", os.ErrNotExist, false},
+		{"decode error", errors.New("invalid index line"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Retryable(tt.err); got != tt.want {
+				t.Errorf("Retryable(%v) = %t, want %t", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package tiles
+
+import (
+	"bytes"
+	"hstin/zephyr/common"
+	"hstin/zephyr/models/base"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"sort"
+)
+
+// Size of a tile in pixels
+const TileSize = 256
+
+// Latitude limit of the Web Mercator projection
+const maxLatitude = 85.0511287798066
+
+// MaxZoom is the highest zoom level that can be requested
+const MaxZoom = 18
+
+// Bounds returns the bounding box of the tile
+func Bounds(z, x, y int) base.BoundingBox {
+	return base.BoundingBox{
+		South: tileLatitude(float64(y+1), z),
+		West:  tileLongitude(float64(x), z),
+		North: tileLatitude(float64(y), z),
+		East:  tileLongitude(float64(x+1), z),
+	}
+}
+
+// ValidTile reports whether the tile exists at the zoom level
+func ValidTile(z, x, y int) bool {
+	if z < 0 || z > MaxZoom {
+		return false
+	}
+	n := 1 << z
+	return x >= 0 && x < n && y >= 0 && y < n
+}
+
+// Resolution returns the width of a pixel in degrees of longitude
+func Resolution(z int) float64 {
+	return 360 / float64(TileSize*(int(1)<<z))
+}
+
+func tileLongitude(x float64, z int) float64 {
+	return x/float64(int(1)<<z)*360 - 180
+}
+
+func tileLatitude(y float64, z int) float64 {
+	return math.Atan(math.Sinh(math.Pi*(1-2*y/float64(int(1)<<z)))) * 180 / math.Pi
+}
+
+// ColorAt returns the color of the value, values outside the ramp use the color of the closest stop
+func ColorAt(ramp []common.ColorStop, value float64) color.NRGBA {
+	if len(ramp) == 0 || math.IsNaN(value) {
+		return color.NRGBA{}
+	}
+
+	i := sort.Search(len(ramp), func(i int) bool { return ramp[i].Value >= value })
+	if i == 0 {
+		return ramp[0].Color
+	}
+	if i == len(ramp) {
+		return ramp[len(ramp)-1].Color
+	}
+
+	lower, upper := ramp[i-1], ramp[i]
+	f := (value - lower.Value) / (upper.Value - lower.Value)
+
+	mix := func(a, b uint8) uint8 {
+		return uint8(math.Round(float64(a) + f*(float64(b)-float64(a))))
+	}
+
+	return color.NRGBA{
+		R: mix(lower.Color.R, upper.Color.R),
+		G: mix(lower.Color.G, upper.Color.G),
+		B: mix(lower.Color.B, upper.Color.B),
+		A: mix(lower.Color.A, upper.Color.A),
+	}
+}
+
+// Render draws the tile from a grid that covers it. Parameters with linear interpolation are
+// interpolated bilinearly between the cells, all others use the nearest cell. Pixels outside
+// of the grid or without a value are transparent.
+func Render(grid base.Grid, parameter common.ParameterOptions, z, x, y int) ([]byte, error) {
+	img := image.NewNRGBA(image.Rect(0, 0, TileSize, TileSize))
+
+	// Grids that cross the antimeridian continue east of 180°
+	grid.Longitudes = unwrapLongitudes(grid.Longitudes)
+
+	longitudes := make([]float64, TileSize)
+	for px := range longitudes {
+		longitudes[px] = tileLongitude(float64(x)+(float64(px)+0.5)/TileSize, z)
+		if len(grid.Longitudes) > 0 && longitudes[px] < grid.Longitudes[0]-Resolution(z) {
+			longitudes[px] += 360
+		}
+	}
+
+	for py := 0; py < TileSize; py++ {
+		latitude := tileLatitude(float64(y)+(float64(py)+0.5)/TileSize, z)
+		if math.Abs(latitude) > maxLatitude {
+			continue
+		}
+
+		for px, longitude := range longitudes {
+			value := sample(grid, latitude, longitude, parameter.InterpolationMethod == common.LINEAR)
+			img.SetNRGBA(px, py, ColorAt(parameter.ColorRamp, value))
+		}
+	}
+
+	var buffer bytes.Buffer
+	encoder := png.Encoder{CompressionLevel: png.BestSpeed}
+	if err := encoder.Encode(&buffer, img); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// unwrapLongitudes returns the longitudes in ascending order by adding 360° after the antimeridian
+func unwrapLongitudes(longitudes []float64) []float64 {
+	unwrapped := make([]float64, len(longitudes))
+	shift := 0.0
+
+	for i, longitude := range longitudes {
+		if i > 0 && longitude+shift < unwrapped[i-1] {
+			shift += 360
+		}
+		unwrapped[i] = longitude + shift
+	}
+
+	return unwrapped
+}
+
+// position returns the index of the cell at or below the coordinate and the fraction towards the
+// next cell. ok is false if the coordinate lies outside of the cells by more than half a cell.
+func position(coordinates []float64, value float64) (int, float64, bool) {
+	n := len(coordinates)
+	if n == 0 {
+		return 0, 0, false
+	}
+	if n == 1 {
+		return 0, 0, true
+	}
+
+	halfCell := (coordinates[1] - coordinates[0]) / 2
+
+	if value < coordinates[0] {
+		return 0, 0, value >= coordinates[0]-halfCell
+	}
+	if value >= coordinates[n-1] {
+		return n - 1, 0, value <= coordinates[n-1]+halfCell
+	}
+
+	i := sort.SearchFloat64s(coordinates, value)
+	if coordinates[i] != value {
+		i--
+	}
+
+	return i, (value - coordinates[i]) / (coordinates[i+1] - coordinates[i]), true
+}
+
+func sample(grid base.Grid, latitude, longitude float64, bilinear bool) float64 {
+	row, rowFraction, ok := position(grid.Latitudes, latitude)
+	if !ok {
+		return math.NaN()
+	}
+
+	column, columnFraction, ok := position(grid.Longitudes, longitude)
+	if !ok {
+		return math.NaN()
+	}
+
+	nx := grid.Nx()
+	value := func(row, column int) float64 {
+		return float64(grid.Values[row*nx+column])
+	}
+
+	if !bilinear || row+1 >= grid.Ny() || column+1 >= nx {
+		return value(row+int(math.Round(rowFraction)), column+int(math.Round(columnFraction)))
+	}
+
+	v00, v01 := value(row, column), value(row, column+1)
+	v10, v11 := value(row+1, column), value(row+1, column+1)
+
+	// Fall back to the nearest cell next to missing values
+	if math.IsNaN(v00) || math.IsNaN(v01) || math.IsNaN(v10) || math.IsNaN(v11) {
+		return value(row+int(math.Round(rowFraction)), column+int(math.Round(columnFraction)))
+	}
+
+	south := v00 + columnFraction*(v01-v00)
+	north := v10 + columnFraction*(v11-v10)
+
+	return south + rowFraction*(north-south)
+}